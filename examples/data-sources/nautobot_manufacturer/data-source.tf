@@ -0,0 +1,3 @@
+data "nautobot_manufacturer" "juniper" {
+  name = "Juniper"
+}
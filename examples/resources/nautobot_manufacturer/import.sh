@@ -0,0 +1,6 @@
+# Manufacturers can be imported by UUID
+terraform import nautobot_manufacturer.juniper 8a1b3f2e-5b5a-4d6e-9f3a-2b1c0d9e8f7a
+
+# or looked up by name, or by slug with Nautobot 1.x
+terraform import nautobot_manufacturer.juniper name=Juniper
+terraform import nautobot_manufacturer.juniper slug=juniper
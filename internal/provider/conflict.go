@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// The values of on_conflict, deciding what Create does when the object
+// already exists in Nautobot.
+const (
+	conflictError           = "error"
+	conflictAdopt           = "adopt"
+	conflictAdoptIfMatching = "adopt_if_matching"
+)
+
+var conflictModes = []string{conflictError, conflictAdopt, conflictAdoptIfMatching}
+
+// onConflictSchema is the on_conflict attribute of the resources, overriding
+// the one of the provider.
+func onConflictSchema(kind string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		ValidateFunc: validation.StringInSlice(conflictModes, false),
+		Description:  fmt.Sprintf("What to do when the %s already exists in Nautobot: `error`, `adopt` to manage the existing one and update it to the configuration, or `adopt_if_matching` to only manage it if its attributes match the configuration. Defaults to the `on_conflict` of the provider.", kind),
+	}
+}
+
+// onConflict returns the on_conflict setting of the resource d, or else the
+// one of the provider.
+func onConflict(d *schema.ResourceData, meta interface{}) string {
+	if v, ok := d.GetOk("on_conflict"); ok {
+		return v.(string)
+	}
+	return meta.(*apiClient).OnConflict
+}
+
+// conflictingFields returns the unique fields rejected by Nautobot when it
+// answered a creation with status and body, which means that another object
+// may already use their values.
+func conflictingFields(status int, body []byte, unique ...string) []string {
+	if status != http.StatusBadRequest {
+		return nil
+	}
+	var fields []string
+	for _, f := range unique {
+		if hasFieldError(body, f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// resolveConflict decides, according to the on_conflict setting, whether the
+// existing object of the given kind, which made action fail, is adopted by
+// the resource d. want are the attributes of the configuration which must
+// match the existing ones with adopt_if_matching.
+func resolveConflict(ctx context.Context, d *schema.ResourceData, meta interface{}, action string, kind string, existing map[string]interface{}, want map[string]interface{}) diag.Diagnostics {
+	id, _ := existing["id"].(string)
+	display, _ := existing["display"].(string)
+	s := meta.(*apiClient).Server
+
+	switch onConflict(d, meta) {
+	case conflictAdopt:
+	case conflictAdoptIfMatching:
+		var diffs []string
+		for k, v := range want {
+			if fmt.Sprint(existing[k]) != fmt.Sprint(v) {
+				diffs = append(diffs, fmt.Sprintf("%s is %q instead of %q", k, fmt.Sprint(existing[k]), fmt.Sprint(v)))
+			}
+		}
+		if len(diffs) > 0 {
+			sort.Strings(diffs)
+			return diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("Existing %s doesn't match", kind),
+				Detail:        fmt.Sprintf("Failed to %s: %s %s already exists on %s with ID %s, and can't be adopted because its %s.", action, kind, display, s, id, strings.Join(diffs, ", ")),
+				AttributePath: cty.GetAttrPath("on_conflict"),
+			}}
+		}
+	default:
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("%s already exists", capitalize(kind)),
+			Detail:        fmt.Sprintf("Failed to %s: %s %s already exists on %s with ID %s. Import it, or set on_conflict to %q or %q to manage it.", action, kind, display, s, id, conflictAdopt, conflictAdoptIfMatching),
+			AttributePath: cty.GetAttrPath("name"),
+		}}
+	}
+
+	tflog.Warn(ctx, fmt.Sprintf("adopting existing %s", kind), map[string]interface{}{
+		"id":      id,
+		"display": display,
+	})
+
+	d.SetId(id)
+
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("Adopted existing %s", kind),
+		Detail:   fmt.Sprintf("%s %s already existed on %s with ID %s, it is now managed by this configuration and will be deleted with it.", capitalize(kind), display, s, id),
+	}}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+
+	nb "github.com/nautobot/go-nautobot/pkg/nautobot"
+)
+
+// The types of custom fields which aren't sent as strings.
+const (
+	customFieldInteger     = "integer"
+	customFieldBoolean     = "boolean"
+	customFieldDate        = "date"
+	customFieldMultiSelect = "multi-select"
+	customFieldJSON        = "json"
+)
+
+// customFieldsDescription documents the custom_fields attribute of resources.
+const customFieldsDescription = "Custom fields, by key, with their values as strings. They are converted to the type of the custom field: " +
+	"integers such as `\"42\"`, booleans such as `\"true\"`, dates such as `\"2023-01-31\"`, multi-selects as JSON lists such as `jsonencode([\"a\", \"b\"])` " +
+	"and JSON fields as JSON documents. An empty string clears the custom field. Only the custom fields set here are managed, the others are left as they are."
+
+// customFields returns the types of the custom fields of contentType, such
+// as "dcim.manufacturer", by key. They are only fetched once per provider.
+func (c *apiClient) customFields(ctx context.Context, contentType string) (map[string]string, diag.Diagnostics) {
+	c.customFieldsMu.Lock()
+	defer c.customFieldsMu.Unlock()
+
+	if fields, ok := c.customFieldTypes[contentType]; ok {
+		return fields, nil
+	}
+
+	list, diags := listAll(ctx, fmt.Sprintf("get %s custom fields from %s", contentType, c.Server), 0, func(ctx context.Context, limit *int, offset *int) (*http.Response, []byte, error) {
+		rsp, err := c.Client.ExtrasCustomFieldsListWithResponse(
+			ctx,
+			&nb.ExtrasCustomFieldsListParams{
+				Limit:  limit,
+				Offset: offset,
+			},
+			// The generated client expects content type IDs, Nautobot takes
+			// their names.
+			extraQuery(url.Values{"content_types": {contentType}}))
+		if err != nil {
+			return nil, nil, err
+		}
+		return rsp.HTTPResponse, rsp.Body, nil
+	})
+	if diags != nil {
+		return nil, diags
+	}
+
+	fields := map[string]string{}
+	for _, item := range list {
+		// Custom fields are identified by their key since Nautobot 2.0, by
+		// their slug since 1.4 and by their name before.
+		var key string
+		for _, k := range []string{"key", "slug", "name"} {
+			if v, ok := item[k].(string); ok && v != "" {
+				key = v
+				break
+			}
+		}
+		switch t := item["type"].(type) {
+		case map[string]interface{}:
+			fields[key], _ = t["value"].(string)
+		case string:
+			fields[key] = t
+		}
+	}
+
+	if c.customFieldTypes == nil {
+		c.customFieldTypes = map[string]map[string]string{}
+	}
+	c.customFieldTypes[contentType] = fields
+
+	return fields, nil
+}
+
+// expandCustomFields converts the custom_fields of a resource to the JSON
+// values expected by Nautobot, according to the types of the custom fields.
+func expandCustomFields(types map[string]string, values map[string]interface{}) (map[string]interface{}, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	data := map[string]interface{}{}
+	for k, v := range values {
+		t, ok := types[k]
+		if !ok {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Unknown custom field",
+				Detail:        fmt.Sprintf("There is no custom field %s for this object in Nautobot.", k),
+				AttributePath: cty.GetAttrPath("custom_fields").IndexString(k),
+			})
+			continue
+		}
+		value, err := expandCustomField(t, v.(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid custom field value",
+				Detail:        fmt.Sprintf("%q is not a valid value for the %s custom field %s: %s", v, t, k, err),
+				AttributePath: cty.GetAttrPath("custom_fields").IndexString(k),
+			})
+			continue
+		}
+		data[k] = value
+	}
+	return data, diags
+}
+
+func expandCustomField(t string, s string) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	switch t {
+	case customFieldInteger:
+		return strconv.ParseInt(s, 10, 64)
+	case customFieldBoolean:
+		return strconv.ParseBool(s)
+	case customFieldDate:
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return nil, fmt.Errorf("it must be a date such as 2023-01-31")
+		}
+		return s, nil
+	case customFieldMultiSelect:
+		var v []string
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("it must be a JSON list of strings such as [\"a\", \"b\"]")
+		}
+		return v, nil
+	case customFieldJSON:
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		return s, nil
+	}
+}
+
+// flattenCustomFields returns the custom field data of an object as the
+// custom_fields of a resource, restricted to the custom fields declared in
+// it, so that only their drift is reported. Declared values equivalent to
+// those of Nautobot, such as "01" for 1, are kept as they are.
+func flattenCustomFields(types map[string]string, declared map[string]interface{}, data map[string]interface{}) map[string]interface{} {
+	values := map[string]interface{}{}
+	for k, v := range declared {
+		current, ok := data[k]
+		if !ok {
+			continue
+		}
+		if want, err := expandCustomField(types[k], v.(string)); err == nil && sameJSON(want, current) {
+			values[k] = v
+			continue
+		}
+		values[k] = flattenCustomField(types[k], current)
+	}
+	return values
+}
+
+// flattenAllCustomFields returns all the custom field data of an object as
+// strings, for data sources.
+func flattenAllCustomFields(v interface{}) map[string]interface{} {
+	values := map[string]interface{}{}
+	data, _ := v.(map[string]interface{})
+	for k, v := range data {
+		values[k] = flattenCustomField("", v)
+	}
+	return values
+}
+
+func flattenCustomField(t string, v interface{}) string {
+	if t == customFieldJSON && v != nil {
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// sameJSON returns whether a and b have the same JSON representation.
+func sameJSON(a interface{}, b interface{}) bool {
+	var ja, jb interface{}
+	for _, v := range []struct {
+		in  interface{}
+		out *interface{}
+	}{{a, &ja}, {b, &jb}} {
+		raw, err := json.Marshal(v.in)
+		if err != nil {
+			return false
+		}
+		if err := json.Unmarshal(raw, v.out); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(ja, jb)
+}
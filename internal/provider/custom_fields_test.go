@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+)
+
+var testCustomFieldTypes = map[string]string{
+	"rack_units": "integer",
+	"eol":        "boolean",
+	"since":      "date",
+	"tier":       "select",
+	"regions":    "multi-select",
+	"extra":      "json",
+	"notes":      "text",
+}
+
+func TestExpandCustomFields(t *testing.T) {
+	data, diags := expandCustomFields(testCustomFieldTypes, map[string]interface{}{
+		"rack_units": "42",
+		"eol":        "true",
+		"since":      "2023-01-31",
+		"tier":       "gold",
+		"regions":    `["emea","apac"]`,
+		"extra":      `{"a":[1,2]}`,
+		"notes":      "",
+	})
+	if diags != nil {
+		t.Fatalf("unexpected error %v", diags)
+	}
+	want := map[string]interface{}{
+		"rack_units": int64(42),
+		"eol":        true,
+		"since":      "2023-01-31",
+		"tier":       "gold",
+		"regions":    []string{"emea", "apac"},
+		"extra":      map[string]interface{}{"a": []interface{}{float64(1), float64(2)}},
+		"notes":      nil,
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("got %#v, want %#v", data, want)
+	}
+
+	for k, v := range map[string]string{
+		"rack_units": "4.5",
+		"eol":        "maybe",
+		"since":      "31/01/2023",
+		"regions":    "emea",
+		"extra":      "{",
+		"unknown":    "x",
+	} {
+		_, diags := expandCustomFields(testCustomFieldTypes, map[string]interface{}{k: v})
+		if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath("custom_fields").IndexString(k)) {
+			t.Errorf("%s = %q: got %v", k, v, diags)
+		}
+	}
+}
+
+func TestFlattenCustomFields(t *testing.T) {
+	got := flattenCustomFields(testCustomFieldTypes, map[string]interface{}{
+		"rack_units": "042",
+		"eol":        "True",
+		"regions":    `["emea", "apac"]`,
+		"extra":      `{"b": 1, "a": 2}`,
+		"tier":       "gold",
+		"notes":      "",
+		"deleted":    "x",
+	}, map[string]interface{}{
+		"rack_units": float64(42),
+		"eol":        false,
+		"regions":    []interface{}{"emea", "apac"},
+		"extra":      map[string]interface{}{"a": float64(2), "b": float64(1)},
+		"tier":       "silver",
+		"notes":      nil,
+		"since":      "2023-01-31",
+	})
+	want := map[string]interface{}{
+		"rack_units": "042",
+		"eol":        "false",
+		"regions":    `["emea", "apac"]`,
+		"extra":      `{"b": 1, "a": 2}`,
+		"tier":       "silver",
+		"notes":      "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
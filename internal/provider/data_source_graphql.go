@@ -6,76 +6,524 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/tidwall/gjson"
 )
 
-func dataSourceGraphQL() *schema.Resource {
-	return &schema.Resource{
-		Description: "Provide an interface to make GraphQL calls to Nautobot as a flexible data source.",
+var (
+	_ datasource.DataSource              = &graphQLDataSource{}
+	_ datasource.DataSourceWithConfigure = &graphQLDataSource{}
+)
+
+// graphQLDataSource runs GraphQL queries. It is served by the framework
+// provider, for the dynamic attributes holding its decoded results.
+type graphQLDataSource struct {
+	client *apiClient
+}
+
+// NewGraphQLDataSource returns the nautobot_graphql data source.
+func NewGraphQLDataSource() datasource.DataSource {
+	return &graphQLDataSource{}
+}
+
+type graphQLDataSourceModel struct {
+	ID                types.String            `tfsdk:"id"`
+	Query             types.String            `tfsdk:"query"`
+	Variables         types.Map               `tfsdk:"variables"`
+	VariablesJSON     types.String            `tfsdk:"variables_json"`
+	OperationName     types.String            `tfsdk:"operation_name"`
+	FailOnPartialData types.Bool              `tfsdk:"fail_on_partial_data"`
+	Extract           types.Map               `tfsdk:"extract"`
+	Pagination        *graphQLPaginationModel `tfsdk:"pagination"`
+	Data              types.String            `tfsdk:"data"`
+	Result            types.Dynamic           `tfsdk:"result"`
+	Extracted         types.Dynamic           `tfsdk:"extracted"`
+}
+
+type graphQLPaginationModel struct {
+	Field       types.String `tfsdk:"field"`
+	PageSize    types.Int64  `tfsdk:"page_size"`
+	Parallelism types.Int64  `tfsdk:"parallelism"`
+}
+
+func (d *graphQLDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_graphql"
+}
 
-		ReadContext: dataSourceGraphQLRead,
+func (d *graphQLDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Provide an interface to make GraphQL calls to Nautobot as a flexible data source.",
 
-		Schema: map[string]*schema.Schema{
-			"query": {
-				Description: "The GraphQL query that will be sent to Nautobot.",
-				Type:        schema.TypeString,
-				Required:    true,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The ID of this resource.",
+				Computed:            true,
 			},
-			"data": {
-				Description: "The data returned by the GraphQL query.",
-				Type:        schema.TypeString,
-				Computed:    true,
+			"query": schema.StringAttribute{
+				MarkdownDescription: "The GraphQL query that will be sent to Nautobot.",
+				Required:            true,
+			},
+			"variables": schema.MapAttribute{
+				MarkdownDescription: "The values of the variables of the query, as strings, such as `{ name = \"Juniper\" }`. Use `variables_json` for the variables of other types.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"variables_json": schema.StringAttribute{
+				MarkdownDescription: "The values of the variables of the query as a JSON-encoded object, for lists, numbers and other nested values, such as `jsonencode({ names = [\"Juniper\", \"Cisco\"], limit = 10 })`. It is merged with `variables`.",
+				Optional:            true,
+			},
+			"operation_name": schema.StringAttribute{
+				MarkdownDescription: "The name of the operation to run, when the query holds several of them.",
+				Optional:            true,
+			},
+			"fail_on_partial_data": schema.BoolAttribute{
+				MarkdownDescription: "Whether GraphQL errors are reported as errors rather than warnings when Nautobot still returns data, such as when a field fails to resolve for some objects. Defaults to `true`.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"extract": schema.MapAttribute{
+				MarkdownDescription: "Values to extract from the data, by name, as [gjson paths](https://github.com/tidwall/gjson/blob/master/SYNTAX.md) such as `virtual_machines.#.id`. They are available in `extracted`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"data": schema.StringAttribute{
+				MarkdownDescription: "The data returned by the GraphQL query.",
+				Computed:            true,
+			},
+			"result": schema.DynamicAttribute{
+				MarkdownDescription: "The data returned by the GraphQL query, decoded. Unlike with `jsondecode(data)`, arrays whose elements all have the same type are lists, the others are tuples.",
+				Computed:            true,
+			},
+			"extracted": schema.DynamicAttribute{
+				MarkdownDescription: "The values extracted from the data, by the names used in `extract`, decoded as in `result`, so that arrays of values of the same type, such as `virtual_machines.#.id`, are lists.",
+				Computed:            true,
+			},
+		},
+
+		Blocks: map[string]schema.Block{
+			"pagination": schema.SingleNestedBlock{
+				MarkdownDescription: "Fetch the objects of a root list field of the query page by page, with `limit` and `offset` arguments added to it, until a page holds less than `page_size` objects. The pages are merged in `data`, the other root fields come from the first page.",
+				Attributes: map[string]schema.Attribute{
+					"field": schema.StringAttribute{
+						MarkdownDescription: "The root list field to paginate, such as `virtual_machines`, or its alias.",
+						Required:            true,
+					},
+					"page_size": schema.Int64Attribute{
+						MarkdownDescription: "The number of objects per page.",
+						Required:            true,
+					},
+					"parallelism": schema.Int64Attribute{
+						MarkdownDescription: "The number of pages fetched at once. Defaults to `1`.",
+						Optional:            true,
+					},
+				},
 			},
 		},
 	}
 }
 
-type reqBody struct {
-	Query string `json:"query"`
+func (d *graphQLDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// The provider isn't configured yet during validation.
+	if req.ProviderData == nil {
+		return
+	}
+	c, ok := req.ProviderData.(*apiClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected provider data",
+			fmt.Sprintf("Expected *apiClient, got %T. This is a bug in the provider.", req.ProviderData),
+		)
+		return
+	}
+	d.client = c
+}
+
+// graphQLRequest is the body of the POST requests sent to the GraphQL API.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
 }
 
-// Use this as reference: https://learn.hashicorp.com/tutorials/terraform/provider-setup?in=terraform/providers#implement-read
-func dataSourceGraphQLRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+func (d *graphQLDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config graphQLDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	strs, err := jsonValue(config.Variables)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("variables"), "Invalid GraphQL variables", err.Error())
+		return
+	}
+	m, _ := strs.(map[string]interface{})
+	variables, vdiags := graphQLVariables(m, config.VariablesJSON.ValueString())
+	resp.Diagnostics.Append(frameworkDiagnostics(vdiags)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.FailOnPartialData.IsNull() {
+		config.FailOnPartialData = types.BoolValue(true)
+	}
+	failOnPartialData := config.FailOnPartialData.ValueBool()
+
+	r := graphQLRequest{
+		Query:         config.Query.ValueString(),
+		Variables:     variables,
+		OperationName: config.OperationName.ValueString(),
+	}
+	var body []byte
 	var diags diag.Diagnostics
+	if config.Pagination != nil {
+		p := graphQLPagination{
+			Field:       config.Pagination.Field.ValueString(),
+			PageSize:    int(config.Pagination.PageSize.ValueInt64()),
+			Parallelism: 1,
+		}
+		if !config.Pagination.Parallelism.IsNull() {
+			p.Parallelism = int(config.Pagination.Parallelism.ValueInt64())
+		}
+		if p.PageSize < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("pagination").AtName("page_size"), "Invalid page size", "The page size must be at least 1.")
+		}
+		if p.Parallelism < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root("pagination").AtName("parallelism"), "Invalid parallelism", "The parallelism must be at least 1.")
+		}
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		body, diags = runPaginatedGraphQL(ctx, d.client, r, p, failOnPartialData)
+	} else {
+		body, diags = runGraphQL(ctx, d.client, r, failOnPartialData)
+	}
+	resp.Diagnostics.Append(frameworkDiagnostics(diags)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := gjson.GetBytes(body, "data")
+	config.Data = types.StringValue(data.Raw)
+	config.Result = types.DynamicValue(terraformValue(data))
+
+	extracted := map[string]attr.Value{}
+	extractedTypes := map[string]attr.Type{}
+	for name, p := range config.Extract.Elements() {
+		v := data.Get(p.(types.String).ValueString())
+		if !v.Exists() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("extract").AtMapKey(name),
+				"Nothing to extract",
+				fmt.Sprintf("The path %s doesn't match anything in the data returned by the GraphQL query.", p),
+			)
+			continue
+		}
+		extracted[name] = terraformValue(v)
+		extractedTypes[name] = extracted[name].Type(ctx)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	config.Extracted = types.DynamicValue(types.ObjectValueMust(extractedTypes, extracted))
+	// always run
+	config.ID = types.StringValue(strconv.FormatInt(time.Now().Unix(), 10))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
 
-	c := meta.(*apiClient).BaseClient
-	s := fmt.Sprintf("%sgraphql/", meta.(*apiClient).Server)
-	t := meta.(*apiClient).Token
-	query := d.Get("query").(string)
+// runGraphQL sends the GraphQL request r to Nautobot and returns the body of
+// its response. The errors of responses which still hold data are reported
+// as warnings, unless failOnPartialData is set.
+func runGraphQL(ctx context.Context, c *apiClient, r graphQLRequest, failOnPartialData bool) ([]byte, diag.Diagnostics) {
+	s := fmt.Sprintf("%sgraphql/", c.Server)
 
-	queryBody, _ := json.Marshal(reqBody{Query: query})
-	req, err := http.NewRequestWithContext(ctx, "POST", s, bytes.NewBuffer(queryBody))
+	if c.ReadOnly && hasMutation(r.Query) {
+		return nil, diag.Errorf("refusing to run a GraphQL mutation because the provider is configured with read_only = true")
+	}
+
+	queryBody, err := json.Marshal(r)
+	if err != nil {
+		return nil, diag.Errorf("failed to encode GraphQL request to %s: %s", s, err.Error())
+	}
+	// GraphQL queries don't modify anything, so they can be retried like a GET.
+	req, err := http.NewRequestWithContext(withIdempotent(ctx), "POST", s, bytes.NewBuffer(queryBody))
 	if err != nil {
-		return diag.Errorf("failed to create request with context %s: %s", s, err.Error())
+		return nil, diag.Errorf("failed to create request with context %s: %s", s, err.Error())
 	}
 	req.Header.Add("Content-Type", "application/json")
-	// Add the authorization header to our request.
-	t.Intercept(ctx, req)
+	// Add the authorization and API version headers to our request.
+	for _, fn := range c.BaseClient.RequestEditors {
+		if err := fn(ctx, req); err != nil {
+			return nil, diag.Errorf("failed to prepare request to %s: %s", s, err.Error())
+		}
+	}
 
-	rsp, err := c.Client.Do(req)
+	rsp, err := c.BaseClient.Client.Do(req)
 	if err != nil {
-		return diag.Errorf("failed to successfully call %s: %s", s, err.Error())
+		return nil, diag.Errorf("failed to successfully call %s: %s", s, err.Error())
 	}
 	defer rsp.Body.Close()
 
 	body, err := io.ReadAll(rsp.Body)
 	if err != nil {
-		return diag.Errorf("failed to decode GraphQL response from %s: %s", s, err.Error())
+		return nil, diag.Errorf("failed to decode GraphQL response from %s: %s", s, err.Error())
 	}
 
-	data := gjson.Get(string(body), "data")
-	if err := d.Set("data", data.Raw); err != nil {
-		return diag.FromErr(err)
+	// Invalid queries are answered with a 400 and only errors.
+	result := gjson.ParseBytes(body)
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		if errs := result.Get("errors"); errs.IsArray() && len(errs.Array()) > 0 {
+			return nil, graphQLErrors(errs, diag.Error)
+		}
+		return nil, checkResponse(rsp, body, "run GraphQL query", nil)
 	}
 
-	// always run
-	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+	errs := result.Get("errors")
+	if !errs.IsArray() || len(errs.Array()) == 0 {
+		return body, nil
+	}
+	if data := result.Get("data"); !data.Exists() || data.Type == gjson.Null || failOnPartialData {
+		return nil, graphQLErrors(errs, diag.Error)
+	}
+	return body, graphQLErrors(errs, diag.Warning)
+}
+
+// graphQLErrors returns the errors of a GraphQL response as diagnostics of
+// the given severity, with their locations in the query and their paths in
+// the data.
+func graphQLErrors(errs gjson.Result, severity diag.Severity) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, e := range errs.Array() {
+		var where []string
+		for _, l := range e.Get("locations").Array() {
+			where = append(where, fmt.Sprintf("line %d, column %d", l.Get("line").Int(), l.Get("column").Int()))
+		}
+		if p := e.Get("path").Array(); len(p) > 0 {
+			elements := make([]string, 0, len(p))
+			for _, v := range p {
+				elements = append(elements, v.String())
+			}
+			where = append(where, fmt.Sprintf("path %s", strings.Join(elements, ".")))
+		}
 
+		detail := e.Get("message").String()
+		if detail == "" {
+			detail = e.Raw
+		}
+		if len(where) > 0 {
+			detail = fmt.Sprintf("%s (%s)", detail, strings.Join(where, "; "))
+		}
+
+		summary := "GraphQL error"
+		if severity == diag.Warning {
+			summary = "GraphQL error in partial data"
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: severity,
+			Summary:  summary,
+			Detail:   detail,
+		})
+	}
 	return diags
 }
+
+// graphQLVariables returns the variables of a GraphQL request, given as
+// strings in variables, and as a JSON-encoded object in variablesJSON.
+func graphQLVariables(variables map[string]interface{}, variablesJSON string) (map[string]interface{}, diag.Diagnostics) {
+	all := map[string]interface{}{}
+	if variablesJSON != "" {
+		dec := json.NewDecoder(strings.NewReader(variablesJSON))
+		// Keep the numbers as they are, GraphQL tells integers from floats.
+		dec.UseNumber()
+		if err := dec.Decode(&all); err != nil {
+			return nil, diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "Invalid GraphQL variables",
+				Detail:        fmt.Sprintf("variables_json must be a JSON-encoded object: %s", err),
+				AttributePath: cty.GetAttrPath("variables_json"),
+			}}
+		}
+	}
+
+	var diags diag.Diagnostics
+	for k, v := range variables {
+		if _, ok := all[k]; ok {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Duplicate GraphQL variable",
+				Detail:        fmt.Sprintf("The variable %s is set both in variables and in variables_json.", k),
+				AttributePath: cty.GetAttrPath("variables").IndexString(k),
+			})
+			continue
+		}
+		all[k] = v
+	}
+	if diags != nil {
+		return nil, diags
+	}
+
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// jsonValue converts a Terraform value to the corresponding JSON value.
+func jsonValue(v attr.Value) (interface{}, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+	if v.IsUnknown() {
+		return nil, fmt.Errorf("value is unknown")
+	}
+
+	var elements []attr.Value
+	var attributes map[string]attr.Value
+	switch v := v.(type) {
+	case basetypes.DynamicValue:
+		return jsonValue(v.UnderlyingValue())
+	case basetypes.StringValue:
+		return v.ValueString(), nil
+	case basetypes.BoolValue:
+		return v.ValueBool(), nil
+	case basetypes.NumberValue:
+		return jsonNumber(v.ValueBigFloat()), nil
+	case basetypes.Int64Value:
+		return v.ValueInt64(), nil
+	case basetypes.Float64Value:
+		return v.ValueFloat64(), nil
+	case basetypes.ListValue:
+		elements = v.Elements()
+	case basetypes.SetValue:
+		elements = v.Elements()
+	case basetypes.TupleValue:
+		elements = v.Elements()
+	case basetypes.MapValue:
+		attributes = v.Elements()
+	case basetypes.ObjectValue:
+		attributes = v.Attributes()
+	default:
+		return nil, fmt.Errorf("unsupported value %s", v)
+	}
+
+	if attributes != nil {
+		m := make(map[string]interface{}, len(attributes))
+		for k, e := range attributes {
+			value, err := jsonValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			m[k] = value
+		}
+		return m, nil
+	}
+
+	l := make([]interface{}, 0, len(elements))
+	for i, e := range elements {
+		value, err := jsonValue(e)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		l = append(l, value)
+	}
+	return l, nil
+}
+
+// terraformValue converts a JSON value to the corresponding Terraform value.
+// Objects are objects, so that their attributes may have different types.
+// Arrays whose elements all have the same type are lists, the others, such
+// as empty ones or those holding nulls, are tuples as with jsondecode.
+func terraformValue(r gjson.Result) attr.Value {
+	switch {
+	case r.IsArray():
+		elements := []attr.Value{}
+		elementTypes := []attr.Type{}
+		for _, e := range r.Array() {
+			v := terraformValue(e)
+			elements = append(elements, v)
+			elementTypes = append(elementTypes, v.Type(context.Background()))
+		}
+		if len(elementTypes) > 0 && !hasDynamicType(elementTypes[0]) {
+			same := true
+			for _, t := range elementTypes[1:] {
+				same = same && t.Equal(elementTypes[0])
+			}
+			if same {
+				return types.ListValueMust(elementTypes[0], elements)
+			}
+		}
+		return types.TupleValueMust(elementTypes, elements)
+	case r.IsObject():
+		attributes := map[string]attr.Value{}
+		attributeTypes := map[string]attr.Type{}
+		r.ForEach(func(k, e gjson.Result) bool {
+			v := terraformValue(e)
+			attributes[k.String()] = v
+			attributeTypes[k.String()] = v.Type(context.Background())
+			return true
+		})
+		return types.ObjectValueMust(attributeTypes, attributes)
+	}
+
+	switch r.Type {
+	case gjson.String:
+		return types.StringValue(r.String())
+	case gjson.Number:
+		f, _, err := big.ParseFloat(r.Raw, 10, 512, big.ToNearestEven)
+		if err != nil {
+			return types.NumberValue(big.NewFloat(r.Float()))
+		}
+		return types.NumberValue(f)
+	case gjson.True, gjson.False:
+		return types.BoolValue(r.Bool())
+	default:
+		return types.DynamicNull()
+	}
+}
+
+// hasDynamicType returns whether t is or holds the dynamic type, which
+// lists can't hold.
+func hasDynamicType(t attr.Type) bool {
+	switch t := t.(type) {
+	case basetypes.DynamicType:
+		return true
+	case basetypes.ListType:
+		return hasDynamicType(t.ElemType)
+	case basetypes.ObjectType:
+		for _, a := range t.AttrTypes {
+			if hasDynamicType(a) {
+				return true
+			}
+		}
+	case basetypes.TupleType:
+		for _, e := range t.ElemTypes {
+			if hasDynamicType(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonNumber formats f as a JSON number, without exponent for integers so
+// that GraphQL accepts them as Int.
+func jsonNumber(f *big.Float) json.Number {
+	if f.IsInt() {
+		return json.Number(f.Text('f', 0))
+	}
+	return json.Number(f.Text('g', -1))
+}
@@ -1,24 +1,31 @@
 package provider
 
 import (
+	"context"
+	"net/http"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/tidwall/gjson"
 )
 
 func TestAccDataSourceGraphQL(t *testing.T) {
-	// https://github.com/hashicorp/terraform-plugin-sdk/issues/952
-	t.Skip("resource not yet implemented, remove this once you add your own code")
+	f := newFakeNautobot(t)
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper"})
 
 	resource.UnitTest(t, resource.TestCase{
-		PreCheck:          func() { testAccPreCheck(t) },
-		ProviderFactories: providerFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: providerFactories,
 		Steps: []resource.TestStep{
 			{
-				Config: testAccDataSourceGraphQL,
+				Config: f.providerConfig() + testAccDataSourceGraphQL,
 				Check: resource.ComposeTestCheckFunc(
-					//resource.TestCheckResourceAttr("data.nautobot_manufacturers.list.manufacturers", "", ""),
-					resource.TestCheckOutput("data_source_graphql", "virtual_machines"),
+					resource.TestCheckOutput("data_source_graphql", `{"manufacturers":[{"name":"Juniper","slug":"juniper"}]}`),
 				),
 			},
 		},
@@ -26,22 +33,281 @@ func TestAccDataSourceGraphQL(t *testing.T) {
 }
 
 const testAccDataSourceGraphQL = `
-provider "nautobot" {
-	url = "https://demo.nautobot.com/api/"
-	token = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
-  }
-
-data "nautobot_graphql" "vms" {
+data "nautobot_graphql" "manufacturers" {
   query = <<EOF
 query {
-  virtual_machines {
-    id
+  manufacturers {
+    name
+    slug
   }
 }
 EOF
 }
 
 output "data_source_graphql" {
-  value = data.nautobot_graphql.vms
+  value = data.nautobot_graphql.manufacturers.data
 }
 `
+
+// readGraphQL runs the nautobot_graphql data source with the attributes in
+// config, and returns its data.
+func readGraphQL(t *testing.T, meta interface{}, config map[string]tftypes.Value) (string, fwdiag.Diagnostics) {
+	t.Helper()
+
+	state, diags := readDataSource(t, NewGraphQLDataSource(), meta, config)
+	if diags.HasError() {
+		return "", diags
+	}
+	var data types.String
+	diags.Append(state.GetAttribute(context.Background(), path.Root("data"), &data)...)
+	return data.ValueString(), diags
+}
+
+func TestDataSourceGraphQLRead(t *testing.T) {
+	f := newFakeNautobot(t)
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper"})
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Cisco"})
+	meta := f.meta(t, nil)
+
+	data, diags := readGraphQL(t, meta, map[string]tftypes.Value{
+		"query": tftypes.NewValue(tftypes.String, `query { manufacturers(name: "Juniper") { name } }`),
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected error %v", diags)
+	}
+	if data != `{"manufacturers":[{"name":"Juniper"}]}` {
+		t.Errorf("got %v", data)
+	}
+
+	f.fail(http.MethodPost, "/api/graphql/", http.StatusBadGateway, "<html>Bad Gateway</html>", 1)
+	if _, diags := readGraphQL(t, f.meta(t, map[string]interface{}{"max_retries": 0}), map[string]tftypes.Value{
+		"query": tftypes.NewValue(tftypes.String, `query { manufacturers { name } }`),
+	}); !diags.HasError() {
+		t.Error("expected an error")
+	}
+}
+
+func TestDataSourceGraphQLVariables(t *testing.T) {
+	f := newFakeNautobot(t)
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper"})
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Cisco"})
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Arista"})
+	meta := f.meta(t, nil)
+
+	const query = `
+query Names($names: [String], $limit: Int) {
+  manufacturers(name: $names, limit: $limit) { name }
+}
+
+query Count { manufacturers { id } }
+`
+
+	for _, tc := range []struct {
+		name          string
+		variables     map[string]string
+		variablesJSON string
+		want          string
+		wantErr       path.Path
+	}{
+		{
+			name:      "map",
+			variables: map[string]string{"names": "Cisco"},
+			want:      `{"manufacturers":[{"name":"Cisco"}]}`,
+		},
+		{
+			name:          "JSON",
+			variablesJSON: `{"names": ["Cisco", "Juniper"], "limit": 5}`,
+			want:          `{"manufacturers":[{"name":"Cisco"},{"name":"Juniper"}]}`,
+		},
+		{
+			name:          "both",
+			variables:     map[string]string{"names": "Juniper"},
+			variablesJSON: `{"limit": 1}`,
+			want:          `{"manufacturers":[{"name":"Juniper"}]}`,
+		},
+		{
+			name:          "duplicate",
+			variables:     map[string]string{"limit": "1"},
+			variablesJSON: `{"limit": 1}`,
+			wantErr:       path.Root("variables").AtMapKey("limit"),
+		},
+		{
+			name:          "not an object",
+			variablesJSON: `["Cisco"]`,
+			wantErr:       path.Root("variables_json"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"query":          tftypes.NewValue(tftypes.String, query),
+				"operation_name": tftypes.NewValue(tftypes.String, "Names"),
+			}
+			if tc.variables != nil {
+				variables := map[string]tftypes.Value{}
+				for k, v := range tc.variables {
+					variables[k] = tftypes.NewValue(tftypes.String, v)
+				}
+				config["variables"] = tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, variables)
+			}
+			if tc.variablesJSON != "" {
+				config["variables_json"] = tftypes.NewValue(tftypes.String, tc.variablesJSON)
+			}
+			data, diags := readGraphQL(t, meta, config)
+			if len(tc.wantErr.Steps()) > 0 {
+				if errs := diags.Errors(); len(errs) != 1 || !diags.Contains(fwdiag.NewAttributeErrorDiagnostic(tc.wantErr, errs[0].Summary(), errs[0].Detail())) {
+					t.Errorf("expected an error on %s, got %v", tc.wantErr, diags)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected error %v", diags)
+			}
+			if data != tc.want {
+				t.Errorf("got %s, want %s", data, tc.want)
+			}
+		})
+	}
+
+	// The operation name picks the query to run.
+	data, diags := readGraphQL(t, meta, map[string]tftypes.Value{
+		"query":          tftypes.NewValue(tftypes.String, query),
+		"operation_name": tftypes.NewValue(tftypes.String, "Count"),
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected error %v", diags)
+	}
+	if got := gjson.Get(data, "manufacturers.#").Int(); got != 3 {
+		t.Errorf("got %d manufacturers", got)
+	}
+}
+
+func TestDataSourceGraphQLErrors(t *testing.T) {
+	f := newFakeNautobot(t)
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper"})
+	meta := f.meta(t, nil)
+
+	for _, tc := range []struct {
+		name              string
+		query             string
+		failOnPartialData tftypes.Value
+		wantSeverity      fwdiag.Severity
+		wantDetail        string
+		wantData          string
+	}{
+		{
+			name:         "invalid query",
+			query:        "query {\n  devices { name }\n}",
+			wantSeverity: fwdiag.SeverityError,
+			wantDetail:   `Cannot query field "devices" on type "Query". (line 2, column 3)`,
+		},
+		{
+			name:         "partial data",
+			query:        "query {\n  manufacturers { name colour }\n}",
+			wantSeverity: fwdiag.SeverityError,
+			wantDetail:   `Cannot resolve field "colour". (line 2, column 24; path manufacturers.0.colour)`,
+		},
+		{
+			name:              "partial data allowed",
+			query:             "query {\n  manufacturers { name colour }\n}",
+			failOnPartialData: tftypes.NewValue(tftypes.Bool, false),
+			wantSeverity:      fwdiag.SeverityWarning,
+			wantDetail:        `Cannot resolve field "colour". (line 2, column 24; path manufacturers.0.colour)`,
+			wantData:          `{"manufacturers":[{"colour":null,"name":"Juniper"}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := map[string]tftypes.Value{
+				"query": tftypes.NewValue(tftypes.String, tc.query),
+			}
+			if !tc.failOnPartialData.IsNull() {
+				config["fail_on_partial_data"] = tc.failOnPartialData
+			}
+			data, diags := readGraphQL(t, meta, config)
+			if len(diags) != 1 || diags[0].Severity() != tc.wantSeverity || diags[0].Detail() != tc.wantDetail {
+				t.Fatalf("got %v, want a %s with %s", diags, tc.wantSeverity, tc.wantDetail)
+			}
+			if data != tc.wantData {
+				t.Errorf("got data %s, want %s", data, tc.wantData)
+			}
+		})
+	}
+}
+
+func TestDataSourceGraphQLExtract(t *testing.T) {
+	f := newFakeNautobot(t)
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper", "description": "Networks"})
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Cisco"})
+	meta := f.meta(t, nil)
+
+	extract := func(paths map[string]string) tftypes.Value {
+		m := map[string]tftypes.Value{}
+		for k, v := range paths {
+			m[k] = tftypes.NewValue(tftypes.String, v)
+		}
+		return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, m)
+	}
+	// colour is null, and only reported with a warning.
+	query := tftypes.NewValue(tftypes.String, `query { manufacturers { name description colour } }`)
+
+	state, diags := readDataSource(t, NewGraphQLDataSource(), meta, map[string]tftypes.Value{
+		"query":                query,
+		"fail_on_partial_data": tftypes.NewValue(tftypes.Bool, false),
+		"extract": extract(map[string]string{
+			"names": "manufacturers.#.name",
+			"first": "manufacturers.0",
+		}),
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected error %v", diags)
+	}
+
+	var result, extracted types.Dynamic
+	state.GetAttribute(context.Background(), path.Root("result"), &result)
+	state.GetAttribute(context.Background(), path.Root("extracted"), &extracted)
+
+	// The manufacturers hold a null colour, so they are a tuple.
+	manufacturers := result.UnderlyingValue().(types.Object).Attributes()["manufacturers"].(types.Tuple).Elements()
+	if len(manufacturers) != 2 {
+		t.Fatalf("got %v", result)
+	}
+	juniper := manufacturers[1].(types.Object).Attributes()
+	if !juniper["name"].Equal(types.StringValue("Juniper")) || !juniper["colour"].IsNull() {
+		t.Errorf("got %v", manufacturers[1])
+	}
+
+	values := extracted.UnderlyingValue().(types.Object).Attributes()
+	if _, ok := values["names"].(types.List); !ok {
+		t.Errorf("got names %s, want a list", values["names"].Type(context.Background()))
+	}
+	if names, err := jsonValue(values["names"]); err != nil || !sameJSON(names, []string{"Cisco", "Juniper"}) {
+		t.Errorf("got names %v", values["names"])
+	}
+	if first, err := jsonValue(values["first"]); err != nil || !sameJSON(first, map[string]interface{}{"name": "Cisco", "description": "", "colour": nil}) {
+		t.Errorf("got first %v", values["first"])
+	}
+
+	_, diags = readDataSource(t, NewGraphQLDataSource(), meta, map[string]tftypes.Value{
+		"query":                query,
+		"fail_on_partial_data": tftypes.NewValue(tftypes.Bool, false),
+		"extract":              extract(map[string]string{"ids": "manufacturer.#.id"}),
+	})
+	if errs := diags.Errors(); len(errs) != 1 || !diags.Contains(fwdiag.NewAttributeErrorDiagnostic(path.Root("extract").AtMapKey("ids"), errs[0].Summary(), errs[0].Detail())) {
+		t.Errorf("expected an error on extract, got %v", diags)
+	}
+}
+
+func TestTerraformValue(t *testing.T) {
+	for raw, want := range map[string]attr.Type{
+		`["a", "b"]`:              types.ListType{ElemType: types.StringType},
+		`[{"a": 1}, {"a": 2}]`:    types.ListType{ElemType: types.ObjectType{AttrTypes: map[string]attr.Type{"a": types.NumberType}}},
+		`[[1], [2, 3]]`:           types.ListType{ElemType: types.ListType{ElemType: types.NumberType}},
+		`["a", 1]`:                types.TupleType{ElemTypes: []attr.Type{types.StringType, types.NumberType}},
+		`[]`:                      types.TupleType{ElemTypes: []attr.Type{}},
+		`[null, null]`:            types.TupleType{ElemTypes: []attr.Type{types.DynamicType, types.DynamicType}},
+		`[{"a": 1}, {"a": null}]`: types.TupleType{ElemTypes: []attr.Type{types.ObjectType{AttrTypes: map[string]attr.Type{"a": types.NumberType}}, types.ObjectType{AttrTypes: map[string]attr.Type{"a": types.DynamicType}}}},
+	} {
+		if got := terraformValue(gjson.Parse(raw)).Type(context.Background()); !got.Equal(want) {
+			t.Errorf("%s: got %s, want %s", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceManufacturer() *schema.Resource {
+	s := dataSourceSchema(resourceManufacturer().Schema, "id", "name", "slug")
+	s["custom_fields"].Description = "Manufacturer's custom fields, by key, with their values as strings."
+	s["tags"].Description = "Manufacturer's tags, by name."
+
+	return &schema.Resource{
+		Description: "Look up a single manufacturer in Nautobot by UUID, name or slug.",
+
+		ReadContext: dataSourceManufacturerRead,
+
+		Schema: s,
+	}
+}
+
+// dataSourceSchema returns the schema of a data source reading the objects of
+// a resource, with the attributes of the resource as computed ones, except for
+// the lookups of which exactly one must be set. Attributes only relevant to
+// writes, such as on_conflict, are left out.
+func dataSourceSchema(resource map[string]*schema.Schema, lookups ...string) map[string]*schema.Schema {
+	s := map[string]*schema.Schema{}
+	for k, v := range resource {
+		if k == "on_conflict" {
+			continue
+		}
+		s[k] = &schema.Schema{
+			Type:        v.Type,
+			Computed:    true,
+			Elem:        v.Elem,
+			Description: v.Description,
+		}
+	}
+	for _, k := range lookups {
+		s[k].Optional = true
+		s[k].ExactlyOneOf = lookups
+	}
+	return s
+}
+
+func dataSourceManufacturerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var field, value string
+	for _, k := range []string{"id", "name", "slug"} {
+		if v, ok := d.GetOk(k); ok {
+			field, value = k, v.(string)
+		}
+	}
+
+	item, err := findManufacturer(ctx, meta, field, value)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	for k, v := range flattenManufacturer(item) {
+		d.Set(k, v)
+	}
+
+	// Unlike the resource, the data source reads every custom field.
+	d.Set("custom_fields", flattenAllCustomFields(item["custom_fields"]))
+
+	if v, ok := item["tags"]; ok {
+		tags, diags := flattenTags(ctx, meta, d.Get("tags").(*schema.Set), v)
+		if diags != nil {
+			return diags
+		}
+		d.Set("tags", tags)
+	}
+
+	d.SetId(item["id"].(string))
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccDataSourceManufacturer(t *testing.T) {
+	f := newFakeNautobot(t)
+	juniper := f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper", "description": "Routers"})
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Cisco"})
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: f.providerConfig() + testAccDataSourceManufacturerByName,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.nautobot_manufacturer.juniper", "id", juniper["id"].(string)),
+					resource.TestCheckResourceAttr("data.nautobot_manufacturer.juniper", "description", "Routers"),
+				),
+			},
+			{
+				Config:      f.providerConfig() + `data "nautobot_manufacturer" "arista" { name = "Arista" }`,
+				ExpectError: regexp.MustCompile("no manufacturer matches name=Arista"),
+			},
+		},
+	})
+}
+
+const testAccDataSourceManufacturerByName = `
+data "nautobot_manufacturer" "juniper" {
+  name = "Juniper"
+}
+`
+
+func TestDataSourceManufacturerRead(t *testing.T) {
+	f := newFakeNautobot(t)
+	juniper := f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper", "description": "Routers"})
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Cisco"})
+	meta := f.meta(t, nil)
+
+	for _, tc := range []struct {
+		raw     map[string]interface{}
+		wantErr string
+	}{
+		{raw: map[string]interface{}{"id": juniper["id"]}},
+		{raw: map[string]interface{}{"name": "Juniper"}},
+		{raw: map[string]interface{}{"slug": "juniper"}},
+		{raw: map[string]interface{}{"name": "Arista"}, wantErr: "no manufacturer matches name=Arista"},
+		{raw: map[string]interface{}{"id": "3f2504e0-4f89-11d3-9a0c-0305e82c3301"}, wantErr: "no manufacturer matches id=3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+	} {
+		d := schema.TestResourceDataRaw(t, dataSourceManufacturer().Schema, tc.raw)
+		diags := dataSourceManufacturerRead(context.Background(), d, meta)
+		if tc.wantErr != "" {
+			if len(diags) != 1 || !regexp.MustCompile(regexp.QuoteMeta(tc.wantErr)).MatchString(diags[0].Summary) {
+				t.Errorf("%v: got %v, want %q", tc.raw, diags, tc.wantErr)
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Errorf("%v: unexpected error %v", tc.raw, diags)
+			continue
+		}
+		if d.Id() != juniper["id"] || d.Get("description") != "Routers" || d.Get("slug") != "juniper" || d.Get("devicetype_count") != 0 {
+			t.Errorf("%v: got ID %s, description %v, slug %v", tc.raw, d.Id(), d.Get("description"), d.Get("slug"))
+		}
+	}
+
+	// Exactly one of id, name and slug is required.
+	for _, raw := range []map[string]interface{}{
+		{},
+		{"name": "Juniper", "slug": "juniper"},
+	} {
+		if diags := dataSourceManufacturer().Validate(terraform.NewResourceConfigRaw(raw)); !diags.HasError() {
+			t.Errorf("%v: expected an error", raw)
+		}
+	}
+}
@@ -2,14 +2,18 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/deepmap/oapi-codegen/pkg/types"
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/tidwall/gjson"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	nb "github.com/nautobot/go-nautobot/pkg/nautobot"
 )
@@ -21,6 +25,63 @@ func dataSourceManufacturers() *schema.Resource {
 		ReadContext: dataSourceManufacturersRead,
 
 		Schema: map[string]*schema.Schema{
+			"cf": {
+				Description: "Only read the manufacturers whose custom fields, by key, have these values.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"created__gte": {
+				Description:      "Only read the manufacturers created on or after this date, such as `2023-01-31`.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validateDate,
+			},
+			"ids": {
+				Description: "Only read the manufacturers with one of these UUIDs, as the `id` filter of the Nautobot API. It is named `ids` because `id` is reserved for the ID of the data source.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsUUID,
+				},
+			},
+			"name": {
+				Description: "Only read the manufacturers with one of these names.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"name__ic": {
+				Description: "Only read the manufacturers whose name contains one of these strings, ignoring case.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"q": {
+				Description: "Only read the manufacturers matching this search, as in the search box of Nautobot.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"slug": {
+				Description: "Only read the manufacturers with one of these slugs. Only supported by Nautobot 1.x.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"tag": {
+				Description: "Only read the manufacturers with one of these tags, by slug with Nautobot 1.x and by name with Nautobot 2.x.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"page_size": {
+				Description:  "Number of manufacturers requested per page, `0` uses the default of Nautobot. Every page is read.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
 			"manufacturers": {
 				Type:     schema.TypeList,
 				Computed: true,
@@ -104,35 +165,95 @@ func dataSourceManufacturers() *schema.Resource {
 
 // Use this as reference: https://learn.hashicorp.com/tutorials/terraform/provider-setup?in=terraform/providers#implement-read
 func dataSourceManufacturersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
-
 	c := meta.(*apiClient).Client
 	s := meta.(*apiClient).Server
 
-	rsp, err := c.DcimManufacturersListWithResponse(
-		ctx,
-		&nb.DcimManufacturersListParams{})
-
-	if err != nil {
-		return diag.Errorf("failed to get manufacturers list from %s: %s", s, err.Error())
+	params, query, diags := dataSourceManufacturersParams(d, meta)
+	if diags != nil {
+		return diags
 	}
 
-	results := gjson.Get(string(rsp.Body), "results")
-	resultsReader := strings.NewReader(results.String())
-
-	list := make([]map[string]interface{}, 0)
+	list, diags := listAll(ctx, fmt.Sprintf("get manufacturers list from %s", s), d.Get("page_size").(int), func(ctx context.Context, limit *int, offset *int) (*http.Response, []byte, error) {
+		params := params
+		params.Limit = limit
+		params.Offset = offset
+		rsp, err := c.DcimManufacturersListWithResponse(ctx, &params, extraQuery(query))
+		if err != nil {
+			return nil, nil, err
+		}
+		return rsp.HTTPResponse, rsp.Body, nil
+	})
+	if diags != nil {
+		return diags
+	}
 
-	err = json.NewDecoder(resultsReader).Decode(&list)
-	if err != nil {
-		return diag.Errorf("failed to decode manufacturers list from %s: %s", s, err.Error())
+	manufacturers := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		m := flattenManufacturer(item)
+		m["custom_fields"] = flattenAllCustomFields(item["custom_fields"])
+		manufacturers = append(manufacturers, m)
 	}
 
-	if err := d.Set("manufacturers", list); err != nil {
+	if err := d.Set("manufacturers", manufacturers); err != nil {
 		return diag.FromErr(err)
 	}
 
 	// always run
 	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
 
-	return diags
+	return nil
+}
+
+// dataSourceManufacturersParams returns the filters of the manufacturers
+// list, with those missing from the generated client as query parameters.
+func dataSourceManufacturersParams(d *schema.ResourceData, meta interface{}) (nb.DcimManufacturersListParams, url.Values, diag.Diagnostics) {
+	var params nb.DcimManufacturersListParams
+	query := url.Values{}
+
+	list := func(k string) *[]string {
+		var values []string
+		for _, v := range d.Get(k).([]interface{}) {
+			values = append(values, v.(string))
+		}
+		if values == nil {
+			return nil
+		}
+		return &values
+	}
+	params.Name = list("name")
+	params.NameIc = list("name__ic")
+	params.Slug = list("slug")
+	if params.Slug != nil && meta.(*apiClient).MajorVersion() >= 2 {
+		return params, nil, diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Slugs not supported",
+			Detail:        fmt.Sprintf("Manufacturers can't be filtered by slug with Nautobot %d.x, they have no slug since Nautobot 2.0.", meta.(*apiClient).MajorVersion()),
+			AttributePath: cty.GetAttrPath("slug"),
+		}}
+	}
+
+	if v, ok := d.GetOk("q"); ok {
+		q := v.(string)
+		params.Q = &q
+	}
+	if ids := list("ids"); ids != nil {
+		uuids := make([]types.UUID, 0, len(*ids))
+		for _, id := range *ids {
+			uuids = append(uuids, uuid.MustParse(id))
+		}
+		params.Id = &uuids
+	}
+	if v, ok := d.GetOk("created__gte"); ok {
+		t, _ := time.Parse(types.DateFormat, v.(string))
+		params.CreatedGte = &types.Date{Time: t}
+	}
+
+	if tags := list("tag"); tags != nil {
+		query["tag"] = *tags
+	}
+	for k, v := range d.Get("cf").(map[string]interface{}) {
+		query.Set("cf_"+k, v.(string))
+	}
+
+	return params, query, nil
 }
@@ -1,36 +1,43 @@
 package provider
 
 import (
+	"context"
+	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func TestAccDataSourceManufacturers(t *testing.T) {
-	// https://github.com/hashicorp/terraform-plugin-sdk/issues/952
-	t.Skip("resource not yet implemented, remove this once you add your own code")
+	f := newFakeNautobot(t)
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper"})
+	f.add("dcim/manufacturers", map[string]interface{}{"name": "Cisco"})
 
 	resource.UnitTest(t, resource.TestCase{
-		PreCheck:          func() { testAccPreCheck(t) },
-		ProviderFactories: providerFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: providerFactories,
 		Steps: []resource.TestStep{
 			{
-				Config: testAccDataSourceManufacturer,
+				Config: f.providerConfig() + testAccDataSourceManufacturer,
 				Check: resource.ComposeTestCheckFunc(
-					//resource.TestCheckResourceAttr("data.nautobot_manufacturers.list.manufacturers", "", ""),
+					resource.TestCheckResourceAttr("data.nautobot_manufacturers.list", "manufacturers.#", "2"),
 					resource.TestCheckOutput("vendor", "juniper"),
 				),
 			},
+			{
+				Config: f.providerConfig() + testAccDataSourceManufacturersFiltered,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.nautobot_manufacturers.filtered", "manufacturers.#", "1"),
+					resource.TestCheckResourceAttr("data.nautobot_manufacturers.filtered", "manufacturers.0.slug", "juniper"),
+				),
+			},
 		},
 	})
 }
 
 const testAccDataSourceManufacturer = `
-provider "nautobot" {
-	url = "https://demo.nautobot.com/api/"
-	token = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
-  }
-
 data "nautobot_manufacturers" "list" {}
 
 variable "filter" {
@@ -43,6 +50,90 @@ output "vendor" {
 	  for manufacturer in data.nautobot_manufacturers.list.manufacturers :
 	  manufacturer.slug
 	  if manufacturer.name == var.filter
-	]
+	][0]
+}
+`
+
+const testAccDataSourceManufacturersFiltered = `
+data "nautobot_manufacturers" "filtered" {
+  name = ["Juniper"]
 }
 `
+
+func TestDataSourceManufacturersRead(t *testing.T) {
+	f := newFakeNautobot(t)
+	for i := 0; i < 120; i++ {
+		f.add("dcim/manufacturers", map[string]interface{}{"name": fmt.Sprintf("Manufacturer %03d", i)})
+	}
+
+	d := dataSourceManufacturers().TestResourceData()
+	if diags := dataSourceManufacturersRead(context.Background(), d, f.meta(t, nil)); diags.HasError() {
+		t.Fatalf("unexpected error %v", diags)
+	}
+	// More than the default page size of the fake Nautobot.
+	if got := d.Get("manufacturers.#"); got != 120 {
+		t.Errorf("got %v manufacturers", got)
+	}
+	if got := d.Get("manufacturers.119.slug"); got != "manufacturer-119" {
+		t.Errorf("got slug %v", got)
+	}
+}
+
+func TestDataSourceManufacturersFilters(t *testing.T) {
+	for _, version := range []string{"1.5.8", "2.1.4"} {
+		t.Run(version, func(t *testing.T) {
+			f := newFakeNautobotVersion(t, version)
+			f.add("extras/tags", map[string]interface{}{"name": "Core Network"})
+			cf := f.add("extras/custom-fields", map[string]interface{}{"label": "Tier", "content_types": []interface{}{"dcim.manufacturer"}})
+			juniper := f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper Networks"})
+			f.add("dcim/manufacturers", map[string]interface{}{"name": "Cisco"})
+			f.add("dcim/manufacturers", map[string]interface{}{"name": "Arista Networks"})["created"] = "2020-01-01"
+			meta := f.meta(t, nil)
+
+			tag := "core-network"
+			if version >= "2" {
+				tag = "Core Network"
+			}
+			tags := f.get("extras/tags")
+			juniper["tags"] = []interface{}{map[string]interface{}{"id": tags[0]["id"]}}
+			key := fakeString(cf["slug"]) + fakeString(cf["key"])
+			juniper["custom_fields"].(map[string]interface{})[key] = "gold"
+
+			for _, tc := range []struct {
+				raw  map[string]interface{}
+				want []string
+			}{
+				{map[string]interface{}{}, []string{"Arista Networks", "Cisco", "Juniper Networks"}},
+				{map[string]interface{}{"name": []interface{}{"Cisco", "Juniper Networks"}}, []string{"Cisco", "Juniper Networks"}},
+				{map[string]interface{}{"name__ic": []interface{}{"networks"}}, []string{"Arista Networks", "Juniper Networks"}},
+				{map[string]interface{}{"q": "cis"}, []string{"Cisco"}},
+				{map[string]interface{}{"ids": []interface{}{juniper["id"]}}, []string{"Juniper Networks"}},
+				{map[string]interface{}{"tag": []interface{}{tag}}, []string{"Juniper Networks"}},
+				{map[string]interface{}{"created__gte": "2021-01-01"}, []string{"Cisco", "Juniper Networks"}},
+				{map[string]interface{}{"cf": map[string]interface{}{key: "gold"}}, []string{"Juniper Networks"}},
+				{map[string]interface{}{"name__ic": []interface{}{"networks"}, "q": "arista"}, []string{"Arista Networks"}},
+			} {
+				d := schema.TestResourceDataRaw(t, dataSourceManufacturers().Schema, tc.raw)
+				if diags := dataSourceManufacturersRead(context.Background(), d, meta); diags.HasError() {
+					t.Fatalf("%v: unexpected error %v", tc.raw, diags)
+				}
+				var got []string
+				for _, m := range d.Get("manufacturers").([]interface{}) {
+					got = append(got, m.(map[string]interface{})["name"].(string))
+				}
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("%v: got %v, want %v", tc.raw, got, tc.want)
+				}
+			}
+
+			d := schema.TestResourceDataRaw(t, dataSourceManufacturers().Schema, map[string]interface{}{"slug": []interface{}{"cisco"}})
+			diags := dataSourceManufacturersRead(context.Background(), d, meta)
+			if version >= "2" && !diags.HasError() {
+				t.Error("expected an error for a slug with Nautobot 2.x")
+			}
+			if version < "2" && (diags.HasError() || d.Get("manufacturers.#") != 1) {
+				t.Errorf("got %v, %v", d.Get("manufacturers"), diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/tidwall/gjson"
+)
+
+// nonFieldErrors is the key used by Django REST framework for validation
+// errors that are not about a single field.
+const nonFieldErrors = "non_field_errors"
+
+// checkResponse returns the diagnostics of a request that failed to action,
+// such as "create manufacturer Juniper", or nil if Nautobot answered with a
+// 2xx status code.
+//
+// Django REST framework answers validation errors with a {field: [messages]}
+// object: each field gets its own diagnostic, whose AttributePath points to
+// the attribute of the same name in attributes, if any. Other errors come
+// with a "detail" message.
+func checkResponse(rsp *http.Response, body []byte, action string, attributes map[string]*schema.Schema) diag.Diagnostics {
+	if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+		return nil
+	}
+
+	summary := fmt.Sprintf("Failed to %s", action)
+	request := fmt.Sprintf("%s %s returned %s", rsp.Request.Method, rsp.Request.URL, rsp.Status)
+
+	result := gjson.ParseBytes(body)
+	if !result.IsObject() {
+		// Probably an HTML page from Django or a reverse proxy.
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   fmt.Sprintf("%s.", request),
+		}}
+	}
+	if d := result.Get("detail"); d.Exists() && d.Type == gjson.String {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   fmt.Sprintf("%s: %s", request, d.String()),
+		}}
+	}
+
+	var diags diag.Diagnostics
+	result.ForEach(func(field, messages gjson.Result) bool {
+		var path cty.Path
+		if _, ok := attributes[field.String()]; ok {
+			path = cty.GetAttrPath(field.String())
+		}
+		diags = append(diags, fieldErrors(summary, request, field.String(), path, messages)...)
+		return true
+	})
+	if len(diags) == 0 {
+		diags = diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   fmt.Sprintf("%s: %s", request, string(body)),
+		}}
+	}
+	return diags
+}
+
+// fieldErrors returns the diagnostics for the validation errors of field.
+// messages is usually a list of strings, but the errors of nested
+// serializers and of custom fields are themselves objects keyed by the
+// nested field, and those of lists are lists of such objects.
+func fieldErrors(summary string, request string, field string, path cty.Path, messages gjson.Result) diag.Diagnostics {
+	if messages.IsObject() {
+		var diags diag.Diagnostics
+		messages.ForEach(func(k, v gjson.Result) bool {
+			var p cty.Path
+			if path != nil {
+				p = path.IndexString(k.String())
+			}
+			diags = append(diags, fieldErrors(summary, request, field+"."+k.String(), p, v)...)
+			return true
+		})
+		return diags
+	}
+
+	var diags diag.Diagnostics
+	var strs []string
+	if messages.IsArray() {
+		for i, m := range messages.Array() {
+			if m.IsObject() || m.IsArray() {
+				var p cty.Path
+				if path != nil {
+					p = path.IndexInt(i)
+				}
+				diags = append(diags, fieldErrors(summary, request, fmt.Sprintf("%s.%d", field, i), p, m)...)
+				continue
+			}
+			strs = append(strs, m.String())
+		}
+	} else {
+		strs = append(strs, messages.String())
+	}
+
+	if len(strs) > 0 {
+		detail := fmt.Sprintf("%s: %s", request, strings.Join(strs, " "))
+		if field != nonFieldErrors {
+			detail = fmt.Sprintf("%s: %s: %s", request, field, strings.Join(strs, " "))
+		}
+		diags = append(diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       summary,
+			Detail:        detail,
+			AttributePath: path,
+		}}, diags...)
+	}
+	return diags
+}
+
+// diagsError returns the errors in diags as an error, for the functions of
+// the SDK that can't return diagnostics, such as importers.
+func diagsError(diags diag.Diagnostics) error {
+	var msgs []string
+	for _, d := range diags {
+		if d.Severity != diag.Error {
+			continue
+		}
+		if d.Detail == "" {
+			msgs = append(msgs, d.Summary)
+			continue
+		}
+		msgs = append(msgs, fmt.Sprintf("%s: %s", d.Summary, d.Detail))
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// hasFieldError returns whether the validation errors in body include one
+// for field.
+func hasFieldError(body []byte, field string) bool {
+	found := false
+	gjson.ParseBytes(body).ForEach(func(k, _ gjson.Result) bool {
+		found = k.String() == field
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,198 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestCheckResponse(t *testing.T) {
+	type want struct {
+		detail string
+		path   cty.Path
+	}
+
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		want   []want
+	}{
+		{
+			name:   "ok",
+			status: http.StatusCreated,
+			body:   `{"id":"8a1b3f2e-5b5a-4d6e-9f3a-2b1c0d9e8f7a"}`,
+		},
+		{
+			name:   "detail",
+			status: http.StatusForbidden,
+			body:   `{"detail":"You do not have permission to perform this action."}`,
+			want: []want{{
+				detail: "POST https://nautobot.example.com/api/dcim/manufacturers/ returned 403 Forbidden: You do not have permission to perform this action.",
+			}},
+		},
+		{
+			name:   "fields",
+			status: http.StatusBadRequest,
+			body:   `{"name":["This field may not be blank."],"slug":["Enter a valid slug.","Too long."],"non_field_errors":["Invalid data."],"owner":["Unknown."]}`,
+			want: []want{
+				{
+					detail: "POST https://nautobot.example.com/api/dcim/manufacturers/ returned 400 Bad Request: name: This field may not be blank.",
+					path:   cty.GetAttrPath("name"),
+				},
+				{
+					detail: "POST https://nautobot.example.com/api/dcim/manufacturers/ returned 400 Bad Request: slug: Enter a valid slug. Too long.",
+					path:   cty.GetAttrPath("slug"),
+				},
+				{
+					detail: "POST https://nautobot.example.com/api/dcim/manufacturers/ returned 400 Bad Request: Invalid data.",
+				},
+				{
+					detail: "POST https://nautobot.example.com/api/dcim/manufacturers/ returned 400 Bad Request: owner: Unknown.",
+				},
+			},
+		},
+		{
+			name:   "nested",
+			status: http.StatusBadRequest,
+			body:   `{"custom_fields":{"rack_units":["Value must be an integer."]}}`,
+			want: []want{{
+				detail: "POST https://nautobot.example.com/api/dcim/manufacturers/ returned 400 Bad Request: custom_fields.rack_units: Value must be an integer.",
+				path:   cty.GetAttrPath("custom_fields").IndexString("rack_units"),
+			}},
+		},
+		{
+			name:   "html",
+			status: http.StatusBadGateway,
+			body:   `<html>Bad Gateway</html>`,
+			want: []want{{
+				detail: "POST https://nautobot.example.com/api/dcim/manufacturers/ returned 502 Bad Gateway.",
+			}},
+		},
+	}
+
+	u, _ := url.Parse("https://nautobot.example.com/api/dcim/manufacturers/")
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := &http.Response{
+				StatusCode: tc.status,
+				Status:     fmt.Sprintf("%d %s", tc.status, http.StatusText(tc.status)),
+				Request:    &http.Request{Method: http.MethodPost, URL: u},
+			}
+
+			diags := checkResponse(rsp, []byte(tc.body), "create manufacturer Juniper", resourceManufacturer().Schema)
+			if len(diags) != len(tc.want) {
+				t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(tc.want), diags)
+			}
+			for i, w := range tc.want {
+				if diags[i].Severity != diag.Error || diags[i].Summary != "Failed to create manufacturer Juniper" {
+					t.Errorf("%d: unexpected diagnostic %v", i, diags[i])
+				}
+				if diags[i].Detail != w.detail {
+					t.Errorf("%d: got detail %q, want %q", i, diags[i].Detail, w.detail)
+				}
+				if !diags[i].AttributePath.Equals(w.path) {
+					t.Errorf("%d: got path %#v, want %#v", i, diags[i].AttributePath, w.path)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceManufacturerErrors(t *testing.T) {
+	const id = "8a1b3f2e-5b5a-4d6e-9f3a-2b1c0d9e8f7a"
+
+	var existing bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/status/":
+			w.Write([]byte(`{"nautobot-version":"1.5.8"}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusBadRequest)
+			if existing {
+				w.Write([]byte(`{"name":["Manufacturer with this name already exists."]}`))
+				return
+			}
+			w.Write([]byte(`{"description":["Ensure this field has no more than 200 characters."]}`))
+		case r.Method == http.MethodPatch:
+			// Adoptions only update the name, the slug is refused.
+			body, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(body), `"slug"`) {
+				w.Write([]byte(`{"id":"` + id + `","name":"Juniper","slug":"juniper","description":""}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"slug":["Enter a valid slug."]}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Not found."}`))
+		default:
+			w.Write([]byte(`{"count":1,"results":[{"id":"` + id + `","name":"Juniper","slug":"juniper","description":"","display":"Juniper","created":"2023-01-01","last_updated":"2023-01-01T00:00:00Z","notes_url":"","url":""}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := New("dev")()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"url":            srv.URL,
+		"token":          testToken,
+		"skip_preflight": true,
+		"api_version":    "1.3",
+	}))
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	meta := p.Meta()
+
+	d := resourceManufacturer().TestResourceData()
+	d.Set("name", "Juniper")
+	diags = resourceManufacturerCreate(context.Background(), d, meta)
+	if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath("description")) {
+		t.Errorf("create: got %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("create: got ID %q", d.Id())
+	}
+
+	// Duplicates are refused unless on_conflict adopts them.
+	existing = true
+	diags = resourceManufacturerCreate(context.Background(), d, meta)
+	if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath("name")) {
+		t.Errorf("create: got %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("create: got ID %q", d.Id())
+	}
+	d.Set("on_conflict", "adopt")
+	if diags := resourceManufacturerCreate(context.Background(), d, meta); diags.HasError() {
+		t.Errorf("create: unexpected error %v", diags)
+	}
+	if d.Id() != id {
+		t.Errorf("create: got ID %q, want %q", d.Id(), id)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceManufacturer().Schema, map[string]interface{}{
+		"name": "Juniper",
+		"slug": "juniper networks",
+	})
+	d.SetId(id)
+	diags = resourceManufacturerUpdate(context.Background(), d, meta)
+	if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath("slug")) {
+		t.Errorf("update: got %v", diags)
+	}
+
+	if diags := resourceManufacturerDelete(context.Background(), d, meta); diags.HasError() {
+		t.Errorf("delete: unexpected error %v", diags)
+	}
+}
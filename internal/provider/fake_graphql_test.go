@@ -0,0 +1,545 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// The fake Nautobot understands the subset of GraphQL used in the tests:
+// queries, possibly named and with variables, whose root fields list the
+// objects of a fakeModel, filtered by their arguments, with nested selections
+// of their fields.
+
+type gqlError struct {
+	Message   string        `json:"message"`
+	Locations []gqlLocation `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+type gqlLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type gqlToken struct {
+	kind  byte // 'n' for names, 's' for strings, '0' for numbers, or the punctuator.
+	value string
+	loc   gqlLocation
+}
+
+type gqlField struct {
+	alias      string
+	name       string
+	args       map[string]gqlValue
+	selections []*gqlField
+	loc        gqlLocation
+}
+
+func (f *gqlField) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// gqlValue is a literal or, when variable is set, a reference to a variable.
+type gqlValue struct {
+	variable string
+	literal  interface{}
+}
+
+type gqlOperation struct {
+	kind string
+	name string
+	// defaults holds the declared variables, with their default values.
+	defaults   map[string]interface{}
+	selections []*gqlField
+}
+
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+}
+
+func gqlTokenize(s string) ([]gqlToken, *gqlError) {
+	var tokens []gqlToken
+	line, col := 1, 1
+	for i := 0; i < len(s); {
+		c := s[i]
+		loc := gqlLocation{Line: line, Column: col}
+		advance := func(n int) {
+			for ; n > 0; n-- {
+				if s[i] == '\n' {
+					line, col = line+1, 1
+				} else {
+					col++
+				}
+				i++
+			}
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			advance(1)
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				advance(1)
+			}
+		case strings.ContainsRune("{}()[]:$!=@", rune(c)):
+			tokens = append(tokens, gqlToken{kind: c, value: string(c), loc: loc})
+			advance(1)
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, &gqlError{Message: "Syntax Error: Unterminated string.", Locations: []gqlLocation{loc}}
+			}
+			v, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, &gqlError{Message: "Syntax Error: Invalid string.", Locations: []gqlLocation{loc}}
+			}
+			tokens = append(tokens, gqlToken{kind: 's', value: v, loc: loc})
+			advance(j + 1 - i)
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(s) && strings.ContainsRune("0123456789.eE+-", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, gqlToken{kind: '0', value: s[i:j], loc: loc})
+			advance(j - i)
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, gqlToken{kind: 'n', value: s[i:j], loc: loc})
+			advance(j - i)
+		default:
+			return nil, &gqlError{Message: fmt.Sprintf("Syntax Error: Unexpected character: %q.", c), Locations: []gqlLocation{loc}}
+		}
+	}
+	return tokens, nil
+}
+
+func (p *gqlParser) peek() *gqlToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *gqlParser) expect(kind byte) (*gqlToken, *gqlError) {
+	t := p.peek()
+	if t == nil {
+		return nil, &gqlError{Message: "Syntax Error: Unexpected <EOF>."}
+	}
+	if t.kind != kind {
+		return nil, &gqlError{Message: fmt.Sprintf("Syntax Error: Unexpected %q.", t.value), Locations: []gqlLocation{t.loc}}
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *gqlParser) document() ([]*gqlOperation, *gqlError) {
+	var ops []*gqlOperation
+	for p.peek() != nil {
+		op := &gqlOperation{kind: "query", defaults: map[string]interface{}{}}
+		if t := p.peek(); t.kind == 'n' {
+			if t.value != "query" && t.value != "mutation" {
+				return nil, &gqlError{Message: fmt.Sprintf("Syntax Error: Unexpected Name %q.", t.value), Locations: []gqlLocation{t.loc}}
+			}
+			op.kind = t.value
+			p.pos++
+			if t := p.peek(); t != nil && t.kind == 'n' {
+				op.name = t.value
+				p.pos++
+			}
+			if t := p.peek(); t != nil && t.kind == '(' {
+				if err := p.variableDefinitions(op); err != nil {
+					return nil, err
+				}
+			}
+		}
+		selections, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		op.selections = selections
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, &gqlError{Message: "Syntax Error: Unexpected <EOF>."}
+	}
+	return ops, nil
+}
+
+func (p *gqlParser) variableDefinitions(op *gqlOperation) *gqlError {
+	p.pos++
+	for {
+		t := p.peek()
+		if t == nil {
+			return &gqlError{Message: "Syntax Error: Unexpected <EOF>."}
+		}
+		if t.kind == ')' {
+			p.pos++
+			return nil
+		}
+		if _, err := p.expect('$'); err != nil {
+			return err
+		}
+		name, err := p.expect('n')
+		if err != nil {
+			return err
+		}
+		if _, err := p.expect(':'); err != nil {
+			return err
+		}
+		// Types are not checked, variables without default are null.
+		op.defaults[name.value] = nil
+		for t := p.peek(); t != nil && strings.ContainsRune("n[]!", rune(t.kind)); t = p.peek() {
+			p.pos++
+		}
+		if t := p.peek(); t != nil && t.kind == '=' {
+			p.pos++
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			op.defaults[name.value] = v.literal
+		}
+	}
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlField, *gqlError) {
+	if _, err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	var fields []*gqlField
+	for {
+		t := p.peek()
+		if t == nil {
+			return nil, &gqlError{Message: "Syntax Error: Expected Name, found <EOF>."}
+		}
+		if t.kind == '}' {
+			p.pos++
+			if len(fields) == 0 {
+				return nil, &gqlError{Message: "Syntax Error: Expected Name, found \"}\".", Locations: []gqlLocation{t.loc}}
+			}
+			return fields, nil
+		}
+
+		name, err := p.expect('n')
+		if err != nil {
+			return nil, err
+		}
+		f := &gqlField{name: name.value, loc: name.loc, args: map[string]gqlValue{}}
+		if t := p.peek(); t != nil && t.kind == ':' {
+			p.pos++
+			name, err := p.expect('n')
+			if err != nil {
+				return nil, err
+			}
+			f.alias, f.name = f.name, name.value
+		}
+		if t := p.peek(); t != nil && t.kind == '(' {
+			p.pos++
+			for t := p.peek(); t != nil && t.kind != ')'; t = p.peek() {
+				arg, err := p.expect('n')
+				if err != nil {
+					return nil, err
+				}
+				if _, err := p.expect(':'); err != nil {
+					return nil, err
+				}
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				f.args[arg.value] = v
+			}
+			if _, err := p.expect(')'); err != nil {
+				return nil, err
+			}
+		}
+		if t := p.peek(); t != nil && t.kind == '{' {
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			f.selections = selections
+		}
+		fields = append(fields, f)
+	}
+}
+
+func (p *gqlParser) value() (gqlValue, *gqlError) {
+	t := p.peek()
+	if t == nil {
+		return gqlValue{}, &gqlError{Message: "Syntax Error: Unexpected <EOF>."}
+	}
+	p.pos++
+	switch t.kind {
+	case '$':
+		name, err := p.expect('n')
+		if err != nil {
+			return gqlValue{}, err
+		}
+		return gqlValue{variable: name.value}, nil
+	case 's':
+		return gqlValue{literal: t.value}, nil
+	case '0':
+		n, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return gqlValue{}, &gqlError{Message: fmt.Sprintf("Syntax Error: Invalid number %q.", t.value), Locations: []gqlLocation{t.loc}}
+		}
+		return gqlValue{literal: n}, nil
+	case 'n':
+		switch t.value {
+		case "true":
+			return gqlValue{literal: true}, nil
+		case "false":
+			return gqlValue{literal: false}, nil
+		case "null":
+			return gqlValue{}, nil
+		}
+		// Enum values.
+		return gqlValue{literal: t.value}, nil
+	case '[':
+		var list []interface{}
+		for t := p.peek(); t != nil && t.kind != ']'; t = p.peek() {
+			v, err := p.value()
+			if err != nil {
+				return gqlValue{}, err
+			}
+			if v.variable != "" {
+				return gqlValue{}, &gqlError{Message: "Variables in lists are not supported by the fake Nautobot.", Locations: []gqlLocation{t.loc}}
+			}
+			list = append(list, v.literal)
+		}
+		if _, err := p.expect(']'); err != nil {
+			return gqlValue{}, err
+		}
+		return gqlValue{literal: list}, nil
+	}
+	return gqlValue{}, &gqlError{Message: fmt.Sprintf("Syntax Error: Unexpected %q.", t.value), Locations: []gqlLocation{t.loc}}
+}
+
+type gqlRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// serveGraphQL answers like graphene-django: documents that can't be
+// parsed or validated get a 400 without data, errors while resolving fields
+// are returned along with the rest of the data.
+func (f *fakeNautobot) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req gqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []gqlError{{Message: "POST body sent invalid JSON."}}})
+		return
+	}
+	if req.Query == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"errors": []gqlError{{Message: "Must provide query string."}}})
+		return
+	}
+
+	data, errs, status := f.executeGraphQL(req)
+	rsp := map[string]interface{}{}
+	if status == http.StatusOK {
+		rsp["data"] = data
+	}
+	if len(errs) > 0 {
+		rsp["errors"] = errs
+	}
+	writeJSON(w, status, rsp)
+}
+
+func (f *fakeNautobot) executeGraphQL(req gqlRequest) (map[string]interface{}, []gqlError, int) {
+	tokens, gerr := gqlTokenize(req.Query)
+	if gerr != nil {
+		return nil, []gqlError{*gerr}, http.StatusBadRequest
+	}
+	p := &gqlParser{tokens: tokens}
+	ops, gerr := p.document()
+	if gerr != nil {
+		return nil, []gqlError{*gerr}, http.StatusBadRequest
+	}
+
+	var op *gqlOperation
+	switch {
+	case req.OperationName != "":
+		for _, o := range ops {
+			if o.name == req.OperationName {
+				op = o
+			}
+		}
+		if op == nil {
+			return nil, []gqlError{{Message: fmt.Sprintf("Unknown operation named %q.", req.OperationName)}}, http.StatusBadRequest
+		}
+	case len(ops) > 1:
+		return nil, []gqlError{{Message: "Must provide operation name if query contains multiple operations."}}, http.StatusBadRequest
+	default:
+		op = ops[0]
+	}
+	if op.kind != "query" {
+		return nil, []gqlError{{Message: "Mutations are not supported by the fake Nautobot."}}, http.StatusBadRequest
+	}
+
+	// Validate the whole document before resolving anything.
+	var errs []gqlError
+	for _, field := range op.selections {
+		model, ok := f.graphqlModel(field.name)
+		if !ok {
+			errs = append(errs, gqlError{Message: fmt.Sprintf("Cannot query field %q on type \"Query\".", field.name), Locations: []gqlLocation{field.loc}})
+			continue
+		}
+		for arg, v := range field.args {
+			if v.variable != "" {
+				if _, ok := req.Variables[v.variable]; !ok {
+					if _, ok := op.defaults[v.variable]; !ok {
+						errs = append(errs, gqlError{Message: fmt.Sprintf("Variable \"$%s\" is not defined.", v.variable), Locations: []gqlLocation{field.loc}})
+					}
+				}
+			}
+			if arg != "limit" && arg != "offset" && arg != "q" && !f.hasField(model, strings.SplitN(arg, "__", 2)[0]) {
+				errs = append(errs, gqlError{Message: fmt.Sprintf("Unknown argument %q on field %q of type \"Query\".", arg, field.name), Locations: []gqlLocation{field.loc}})
+			}
+		}
+		if field.selections == nil {
+			errs = append(errs, gqlError{Message: fmt.Sprintf("Field %q of type \"[%sType]\" must have a sub selection.", field.name, field.name), Locations: []gqlLocation{field.loc}})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs, http.StatusBadRequest
+	}
+
+	data := map[string]interface{}{}
+	for _, field := range op.selections {
+		model, _ := f.graphqlModel(field.name)
+		args := url.Values{}
+		limit, offset := -1, 0
+		for arg, v := range field.args {
+			value := v.literal
+			if v.variable != "" {
+				value = op.defaults[v.variable]
+				if rv, ok := req.Variables[v.variable]; ok {
+					value = rv
+				}
+			}
+			switch {
+			case value == nil:
+				// Null arguments are ignored.
+			case arg == "limit":
+				limit = int(toFloat(value))
+			case arg == "offset":
+				offset = int(toFloat(value))
+			default:
+				if list, ok := value.([]interface{}); ok {
+					for _, item := range list {
+						args.Add(arg, fakeString(item))
+					}
+				} else {
+					args.Add(arg, fakeString(value))
+				}
+			}
+		}
+
+		objects, _ := f.filter(model, args)
+		if offset > len(objects) {
+			offset = len(objects)
+		}
+		objects = objects[offset:]
+		if limit >= 0 && limit < len(objects) {
+			objects = objects[:limit]
+		}
+
+		list := make([]interface{}, 0, len(objects))
+		for i, obj := range objects {
+			v, ferrs := gqlSelect(obj, field.selections, []interface{}{field.key(), i})
+			errs = append(errs, ferrs...)
+			list = append(list, v)
+		}
+		data[field.key()] = list
+	}
+
+	return data, errs, http.StatusOK
+}
+
+func (f *fakeNautobot) graphqlModel(name string) (string, bool) {
+	for endpoint, model := range fakeModels {
+		if model.graphql == name {
+			return endpoint, true
+		}
+	}
+	return "", false
+}
+
+// gqlSelect returns the fields of obj in selections. Unknown fields resolve
+// to null with an error, like fields whose resolver fails in Nautobot.
+func gqlSelect(obj map[string]interface{}, selections []*gqlField, path []interface{}) (map[string]interface{}, []gqlError) {
+	var errs []gqlError
+	rsp := map[string]interface{}{}
+	for _, field := range selections {
+		fieldPath := append(append([]interface{}{}, path...), field.key())
+		v, ok := obj[field.name]
+		if !ok {
+			errs = append(errs, gqlError{
+				Message:   fmt.Sprintf("Cannot resolve field %q.", field.name),
+				Locations: []gqlLocation{field.loc},
+				Path:      fieldPath,
+			})
+			rsp[field.key()] = nil
+			continue
+		}
+
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if field.selections == nil {
+				rsp[field.key()] = v
+				continue
+			}
+			nested, nerrs := gqlSelect(v, field.selections, fieldPath)
+			errs = append(errs, nerrs...)
+			rsp[field.key()] = nested
+		case []interface{}:
+			if field.selections == nil {
+				rsp[field.key()] = v
+				continue
+			}
+			list := make([]interface{}, 0, len(v))
+			for i, item := range v {
+				m, _ := item.(map[string]interface{})
+				nested, nerrs := gqlSelect(m, field.selections, append(append([]interface{}{}, fieldPath...), i))
+				errs = append(errs, nerrs...)
+				list = append(list, nested)
+			}
+			rsp[field.key()] = list
+		default:
+			rsp[field.key()] = v
+		}
+	}
+	return rsp, errs
+}
+
+func toFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
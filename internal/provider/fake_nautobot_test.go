@@ -0,0 +1,828 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const (
+	// fakePageSize and fakeMaxPageSize are the PAGINATE_COUNT and
+	// MAX_PAGE_SIZE of the fake Nautobot.
+	fakePageSize    = 50
+	fakeMaxPageSize = 1000
+)
+
+// fakeModel describes how the fake Nautobot stores the objects of an
+// endpoint.
+type fakeModel struct {
+	// graphql is the name of the GraphQL field listing the objects.
+	graphql string
+	// required fields must be set on creation, unique ones can't be shared
+	// by two objects.
+	required []string
+	unique   []string
+	// defaults sets the fields computed by Nautobot on creation, depending on
+	// its major version.
+	defaults func(major int, obj map[string]interface{})
+	// contentType is the content type of the objects, set for those which
+	// have custom fields.
+	contentType string
+	// taggable objects have tags, set by ID.
+	taggable bool
+}
+
+var fakeModels = map[string]fakeModel{
+	"dcim/manufacturers": {
+		graphql:  "manufacturers",
+		required: []string{"name"},
+		unique:   []string{"name", "slug"},
+		defaults: func(major int, obj map[string]interface{}) {
+			setDefault(obj, "description", "")
+			if major >= 2 {
+				delete(obj, "slug")
+				for _, k := range []string{"device_type_count", "inventory_item_count", "platform_count"} {
+					obj[k] = float64(0)
+				}
+				return
+			}
+			setDefault(obj, "slug", slugify(obj["name"]))
+			for _, k := range []string{"devicetype_count", "inventoryitem_count", "platform_count"} {
+				obj[k] = float64(0)
+			}
+		},
+		contentType: "dcim.manufacturer",
+		taggable:    true,
+	},
+	"extras/tags": {
+		graphql:  "tags",
+		required: []string{"name"},
+		unique:   []string{"name", "slug"},
+		defaults: func(major int, obj map[string]interface{}) {
+			setDefault(obj, "color", "9e9e9e")
+			if major >= 2 {
+				delete(obj, "slug")
+				return
+			}
+			setDefault(obj, "slug", slugify(obj["name"]))
+		},
+	},
+	"extras/custom-fields": {
+		graphql:  "custom_fields",
+		required: []string{"label"},
+		unique:   []string{"key", "slug", "name"},
+		// Custom fields are added with their type as a string, such as
+		// "integer", and content types such as "dcim.manufacturer".
+		defaults: func(major int, obj map[string]interface{}) {
+			if t, ok := obj["type"].(string); ok {
+				obj["type"] = map[string]interface{}{"value": t, "label": t}
+			}
+			setDefault(obj, "type", map[string]interface{}{"value": "text", "label": "text"})
+			setDefault(obj, "content_types", []interface{}{})
+			key := strings.ReplaceAll(slugify(obj["label"]), "-", "_")
+			if major >= 2 {
+				setDefault(obj, "key", key)
+				return
+			}
+			setDefault(obj, "slug", key)
+			setDefault(obj, "name", obj["slug"])
+		},
+	},
+}
+
+// fakeFailure is an error injected in the answers of the fake Nautobot.
+type fakeFailure struct {
+	method string
+	path   string
+	status int
+	body   string
+	times  int
+}
+
+// fakeNautobot is an in-memory Nautobot implementing the REST API endpoints
+// and the GraphQL queries used by the provider, for tests to run offline.
+// Lists, retrievals, creations, partial updates and deletions follow the
+// semantics of Django REST framework, including validation errors and limit
+// and offset pagination.
+type fakeNautobot struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	version  string
+	objects  map[string][]map[string]interface{}
+	failures []*fakeFailure
+	requests []string
+}
+
+// newFakeNautobot starts a fake Nautobot 1.5, closed at the end of the test.
+func newFakeNautobot(t *testing.T) *fakeNautobot {
+	t.Helper()
+
+	return newFakeNautobotVersion(t, "1.5.8")
+}
+
+// newFakeNautobotVersion starts a fake Nautobot running version, such as
+// "2.1.4", closed at the end of the test.
+func newFakeNautobotVersion(t *testing.T, version string) *fakeNautobot {
+	t.Helper()
+
+	f := &fakeNautobot{
+		version: version,
+		objects: map[string][]map[string]interface{}{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// providerConfig returns the configuration of a provider using f.
+func (f *fakeNautobot) providerConfig() string {
+	return fmt.Sprintf(`
+provider "nautobot" {
+  url   = %q
+  token = %q
+}
+`, f.URL, testToken)
+}
+
+// meta returns the apiClient of a provider using f, configured with the
+// extra attributes in raw.
+func (f *fakeNautobot) meta(t *testing.T, raw map[string]interface{}) interface{} {
+	t.Helper()
+
+	config := map[string]interface{}{
+		"url":   f.URL,
+		"token": testToken,
+	}
+	for k, v := range raw {
+		config[k] = v
+	}
+
+	p := New("dev")()
+	if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(config)); diags.HasError() {
+		t.Fatalf("failed to configure the provider: %v", diags)
+	}
+	return p.Meta()
+}
+
+// add stores obj as if it had been created through endpoint, such as
+// "dcim/manufacturers", and returns it with the fields set by Nautobot.
+func (f *fakeNautobot) add(endpoint string, obj map[string]interface{}) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.insert(endpoint, obj)
+}
+
+// get returns the objects of endpoint.
+func (f *fakeNautobot) get(endpoint string) []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]map[string]interface{}{}, f.objects[endpoint]...)
+}
+
+// fail makes the next times requests matching method and path fail with
+// status and body. An empty method matches every method, and path matches
+// the requests whose path starts with it.
+func (f *fakeNautobot) fail(method string, path string, status int, body string, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = append(f.failures, &fakeFailure{method: method, path: path, status: status, body: body, times: times})
+}
+
+// requestLog returns the "METHOD path" of the requests received so far.
+func (f *fakeNautobot) requestLog() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string{}, f.requests...)
+}
+
+var fakeObjectPath = regexp.MustCompile(`^/api/([a-z-]+/[a-z-]+)/(?:([0-9a-f-]{36})/)?$`)
+
+func (f *fakeNautobot) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	for _, failure := range f.failures {
+		if failure.times > 0 && (failure.method == "" || failure.method == r.Method) && strings.HasPrefix(r.URL.Path, failure.path) {
+			failure.times--
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(failure.status)
+			io.WriteString(w, failure.body)
+			return
+		}
+	}
+
+	if r.Header.Get("Authorization") != "Token "+testToken {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"detail": "Invalid token"})
+		return
+	}
+
+	// Nautobot defaults to its latest API version, and only supports those of
+	// its major version.
+	apiVersion := f.version[:strings.LastIndex(f.version, ".")]
+	if _, v, ok := strings.Cut(r.Header.Get("Accept"), "version="); ok {
+		if v[0] != apiVersion[0] || v > apiVersion {
+			writeJSON(w, http.StatusNotAcceptable, map[string]interface{}{"detail": `Invalid version in "Accept" header.`})
+			return
+		}
+		apiVersion = v
+	}
+	w.Header().Set("API-Version", apiVersion)
+
+	switch r.URL.Path {
+	case "/api/status/":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"nautobot-version": f.version})
+		return
+	case "/api/users/tokens/":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"count":    1,
+			"next":     nil,
+			"previous": nil,
+			"results":  []interface{}{map[string]interface{}{"key": testToken, "write_enabled": true}},
+		})
+		return
+	case "/api/graphql/":
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"detail": fmt.Sprintf("Method %q not allowed.", r.Method)})
+			return
+		}
+		f.serveGraphQL(w, r)
+		return
+	}
+
+	m := fakeObjectPath.FindStringSubmatch(r.URL.Path)
+	if m == nil {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"detail": "Not found."})
+		return
+	}
+	endpoint, id := m[1], m[2]
+	if _, ok := fakeModels[endpoint]; !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"detail": "Not found."})
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		f.list(w, r, endpoint)
+	case id == "" && r.Method == http.MethodPost:
+		f.create(w, r, endpoint)
+	case id != "" && r.Method == http.MethodGet:
+		if obj := f.find(endpoint, id); obj != nil {
+			writeJSON(w, http.StatusOK, obj)
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"detail": "Not found."})
+	case id != "" && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		f.update(w, r, endpoint, id)
+	case id != "" && r.Method == http.MethodDelete:
+		for i, obj := range f.objects[endpoint] {
+			if obj["id"] == id {
+				f.objects[endpoint] = append(f.objects[endpoint][:i], f.objects[endpoint][i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"detail": "Not found."})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"detail": fmt.Sprintf("Method %q not allowed.", r.Method)})
+	}
+}
+
+func (f *fakeNautobot) list(w http.ResponseWriter, r *http.Request, endpoint string) {
+	query := r.URL.Query()
+
+	limit, offset := fakePageSize, 0
+	if v := query.Get("limit"); v != "" {
+		limit, _ = strconv.Atoi(v)
+		if limit <= 0 || limit > fakeMaxPageSize {
+			limit = fakeMaxPageSize
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		offset, _ = strconv.Atoi(v)
+	}
+	query.Del("limit")
+	query.Del("offset")
+
+	objects, errs := f.filter(endpoint, query)
+	if errs != nil {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	page := make([]interface{}, 0)
+	for i := offset; i < offset+limit && i < len(objects); i++ {
+		page = append(page, objects[i])
+	}
+
+	link := func(offset int) interface{} {
+		u := *r.URL
+		q := u.Query()
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(offset))
+		u.RawQuery = q.Encode()
+		return f.URL + u.RequestURI()
+	}
+	var next, previous interface{}
+	if offset+limit < len(objects) {
+		next = link(offset + limit)
+	}
+	if offset > 0 {
+		previous = link(max(offset-limit, 0))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"count":    len(objects),
+		"next":     next,
+		"previous": previous,
+		"results":  page,
+	})
+}
+
+// filter returns the objects of endpoint matching the filters, which are
+// field names with an optional lookup, such as name__ic, or q. It returns
+// the errors to send back for unknown filters.
+func (f *fakeNautobot) filter(endpoint string, filters url.Values) ([]map[string]interface{}, map[string]interface{}) {
+	objects := f.objects[endpoint]
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := filters[key]
+		field, lookup, _ := strings.Cut(key, "__")
+		match := func(obj map[string]interface{}) bool {
+			return fakeMatch(obj, field, lookup, values)
+		}
+		switch {
+		case field == "q":
+		case field == "tag" && fakeModels[endpoint].taggable:
+			match = func(obj map[string]interface{}) bool {
+				return f.hasTag(obj, values)
+			}
+		case strings.HasPrefix(field, "cf_") && fakeModels[endpoint].contentType != "":
+			cf := strings.TrimPrefix(field, "cf_")
+			if _, ok := f.customFieldTypes(fakeModels[endpoint].contentType)[cf]; !ok {
+				return nil, map[string]interface{}{key: []string{"Unknown filter field"}}
+			}
+			match = func(obj map[string]interface{}) bool {
+				data, _ := obj["custom_fields"].(map[string]interface{})
+				return fakeMatch(data, cf, lookup, values)
+			}
+		case !f.hasField(endpoint, field):
+			return nil, map[string]interface{}{key: []string{"Unknown filter field"}}
+		}
+
+		var matching []map[string]interface{}
+		for _, obj := range objects {
+			if match(obj) {
+				matching = append(matching, obj)
+			}
+		}
+		objects = matching
+	}
+
+	return objects, nil
+}
+
+// hasTag returns whether obj has one of the tags, by slug with Nautobot 1.x
+// and by name or ID with Nautobot 2.x.
+func (f *fakeNautobot) hasTag(obj map[string]interface{}, values []string) bool {
+	tags, _ := obj["tags"].([]interface{})
+	for _, nested := range tags {
+		tag := f.find("extras/tags", fakeString(nested.(map[string]interface{})["id"]))
+		for _, v := range values {
+			if f.major() >= 2 && (tag["name"] == v || tag["id"] == v) || f.major() < 2 && tag["slug"] == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// major returns the major version of the fake Nautobot.
+func (f *fakeNautobot) major() int {
+	major, _ := strconv.Atoi(f.version[:strings.Index(f.version, ".")])
+	return major
+}
+
+// hasField returns whether the objects of endpoint have field, looking at
+// the fields always set on creation.
+func (f *fakeNautobot) hasField(endpoint string, field string) bool {
+	obj := map[string]interface{}{"name": "x"}
+	fakeModels[endpoint].defaults(f.major(), obj)
+	for _, k := range []string{"id", "created", "last_updated", "display", "url", "notes_url"} {
+		obj[k] = ""
+	}
+	_, ok := obj[field]
+	return ok
+}
+
+func fakeMatch(obj map[string]interface{}, field string, lookup string, values []string) bool {
+	if field == "q" {
+		for _, v := range values {
+			if strings.Contains(strings.ToLower(fakeString(obj["name"])), strings.ToLower(v)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Filters on lists match the objects containing one of the values.
+	if list, ok := obj[field].([]interface{}); ok {
+		for _, item := range list {
+			if fakeMatch(map[string]interface{}{field: item}, field, lookup, values) {
+				return true
+			}
+		}
+		return false
+	}
+
+	got := fakeString(obj[field])
+	negate := strings.HasPrefix(lookup, "n")
+	if negate && lookup != "" {
+		lookup = lookup[1:]
+	}
+
+	for _, v := range values {
+		var ok bool
+		switch lookup {
+		case "":
+			ok = got == v
+		case "ie":
+			ok = strings.EqualFold(got, v)
+		case "ic":
+			ok = strings.Contains(strings.ToLower(got), strings.ToLower(v))
+		case "isw":
+			ok = strings.HasPrefix(strings.ToLower(got), strings.ToLower(v))
+		case "iew":
+			ok = strings.HasSuffix(strings.ToLower(got), strings.ToLower(v))
+		case "gte":
+			ok = got >= v
+		case "lte":
+			ok = got <= v
+		case "gt":
+			ok = got > v
+		case "lt":
+			ok = got < v
+		}
+		if ok {
+			return !negate
+		}
+	}
+	return negate
+}
+
+func fakeString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (f *fakeNautobot) find(endpoint string, id string) map[string]interface{} {
+	for _, obj := range f.objects[endpoint] {
+		if obj["id"] == id {
+			return obj
+		}
+	}
+	return nil
+}
+
+func (f *fakeNautobot) create(w http.ResponseWriter, r *http.Request, endpoint string) {
+	var obj map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"detail": fmt.Sprintf("JSON parse error - %s", err)})
+		return
+	}
+
+	model := fakeModels[endpoint]
+	errs := map[string]interface{}{}
+	for _, k := range model.required {
+		if v, ok := obj[k]; !ok || v == nil {
+			errs[k] = []string{"This field is required."}
+		} else if v == "" {
+			errs[k] = []string{"This field may not be blank."}
+		}
+	}
+	if len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	if errs := f.checkCustomFields(endpoint, obj["custom_fields"]); errs != nil {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	if errs := f.nestTags(endpoint, obj); errs != nil {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	for _, k := range []string{"id", "created", "last_updated", "display", "url", "notes_url"} {
+		delete(obj, k)
+	}
+	model.defaults(f.major(), obj)
+	if errs := f.checkUnique(endpoint, obj, ""); errs != nil {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, f.insert(endpoint, obj))
+}
+
+func (f *fakeNautobot) insert(endpoint string, obj map[string]interface{}) map[string]interface{} {
+	fakeModels[endpoint].defaults(f.major(), obj)
+
+	if fakeModels[endpoint].taggable {
+		setDefault(obj, "tags", []interface{}{})
+	}
+	if contentType := fakeModels[endpoint].contentType; contentType != "" {
+		data, _ := obj["custom_fields"].(map[string]interface{})
+		obj["custom_fields"] = f.mergeCustomFields(contentType, nil, data)
+	}
+
+	now := time.Now().UTC()
+	id := uuid.NewString()
+	obj["id"] = id
+	obj["created"] = now.Format("2006-01-02")
+	if f.major() >= 2 {
+		obj["created"] = now.Format("2006-01-02T15:04:05.000000Z")
+	}
+	obj["last_updated"] = now.Format("2006-01-02T15:04:05.000000Z")
+	obj["display"] = fakeDisplay(obj)
+	obj["url"] = fmt.Sprintf("%s/api/%s/%s/", f.URL, endpoint, id)
+	obj["notes_url"] = fmt.Sprintf("%s/api/%s/%s/notes/", f.URL, endpoint, id)
+
+	f.objects[endpoint] = append(f.objects[endpoint], obj)
+	sort.SliceStable(f.objects[endpoint], func(i, j int) bool {
+		return fakeString(f.objects[endpoint][i]["name"]) < fakeString(f.objects[endpoint][j]["name"])
+	})
+
+	return obj
+}
+
+func (f *fakeNautobot) update(w http.ResponseWriter, r *http.Request, endpoint string, id string) {
+	obj := f.find(endpoint, id)
+	if obj == nil {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"detail": "Not found."})
+		return
+	}
+
+	var changes map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"detail": fmt.Sprintf("JSON parse error - %s", err)})
+		return
+	}
+	for _, k := range []string{"id", "created", "last_updated", "display", "url", "notes_url"} {
+		delete(changes, k)
+	}
+	for _, k := range fakeModels[endpoint].required {
+		if v, ok := changes[k]; ok && (v == nil || v == "") {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{k: []string{"This field may not be blank."}})
+			return
+		}
+	}
+
+	if errs := f.nestTags(endpoint, changes); errs != nil {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	// Custom field data is merged with the current one.
+	if data, ok := changes["custom_fields"]; ok {
+		if errs := f.checkCustomFields(endpoint, data); errs != nil {
+			writeJSON(w, http.StatusBadRequest, errs)
+			return
+		}
+		current, _ := obj["custom_fields"].(map[string]interface{})
+		data, _ := data.(map[string]interface{})
+		changes["custom_fields"] = f.mergeCustomFields(fakeModels[endpoint].contentType, current, data)
+	}
+
+	updated := map[string]interface{}{}
+	for k, v := range obj {
+		updated[k] = v
+	}
+	for k, v := range changes {
+		updated[k] = v
+	}
+	if errs := f.checkUnique(endpoint, updated, id); errs != nil {
+		writeJSON(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	for k, v := range changes {
+		obj[k] = v
+	}
+	obj["display"] = fakeDisplay(obj)
+	obj["last_updated"] = time.Now().UTC().Format("2006-01-02T15:04:05.000000Z")
+
+	writeJSON(w, http.StatusOK, obj)
+}
+
+// checkUnique returns the validation errors for the unique fields of obj
+// already used by another object than id.
+func (f *fakeNautobot) checkUnique(endpoint string, obj map[string]interface{}, id string) map[string]interface{} {
+	errs := map[string]interface{}{}
+	model := strings.TrimSuffix(endpoint[strings.Index(endpoint, "/")+1:], "s")
+	for _, k := range fakeModels[endpoint].unique {
+		if _, ok := obj[k]; !ok {
+			continue
+		}
+		for _, other := range f.objects[endpoint] {
+			if other["id"] != id && fakeString(other[k]) == fakeString(obj[k]) {
+				errs[k] = []string{fmt.Sprintf("%s with this %s already exists.", model, k)}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// customFieldTypes returns the types of the custom fields of contentType by
+// key.
+func (f *fakeNautobot) customFieldTypes(contentType string) map[string]string {
+	types := map[string]string{}
+	for _, cf := range f.objects["extras/custom-fields"] {
+		for _, ct := range cf["content_types"].([]interface{}) {
+			if ct == contentType {
+				key := fakeString(cf["slug"])
+				if f.major() >= 2 {
+					key = fakeString(cf["key"])
+				}
+				types[key] = fakeString(cf["type"].(map[string]interface{})["value"])
+			}
+		}
+	}
+	return types
+}
+
+// checkCustomFields returns the validation errors of the custom field data
+// sent for an object of endpoint.
+func (f *fakeNautobot) checkCustomFields(endpoint string, data interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	contentType := fakeModels[endpoint].contentType
+	values, ok := data.(map[string]interface{})
+	if !ok || contentType == "" {
+		return map[string]interface{}{"custom_fields": []string{"Invalid data. Expected a dictionary."}}
+	}
+
+	types := f.customFieldTypes(contentType)
+	errs := map[string]interface{}{}
+	for k, v := range values {
+		t, ok := types[k]
+		if !ok {
+			errs[k] = []string{fmt.Sprintf("Unknown field name '%s' in custom field data.", k)}
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		var msg string
+		switch t {
+		case "integer":
+			if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+				msg = "Value must be an integer."
+			}
+		case "boolean":
+			if _, ok := v.(bool); !ok {
+				msg = "Value must be true or false."
+			}
+		case "date":
+			if _, err := time.Parse("2006-01-02", fakeString(v)); err != nil {
+				msg = "Date values must be in the format YYYY-MM-DD."
+			}
+		case "multi-select":
+			if _, ok := v.([]interface{}); !ok {
+				msg = "Value must be a list."
+			}
+		case "json":
+		default:
+			if _, ok := v.(string); !ok {
+				msg = "Value must be a string."
+			}
+		}
+		if msg != "" {
+			errs[k] = []string{msg}
+		}
+	}
+	if len(errs) > 0 {
+		return map[string]interface{}{"custom_fields": errs}
+	}
+	return nil
+}
+
+// mergeCustomFields returns the custom field data of an object of
+// contentType once data is applied to current, with every custom field set.
+func (f *fakeNautobot) mergeCustomFields(contentType string, current map[string]interface{}, data map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k := range f.customFieldTypes(contentType) {
+		merged[k] = nil
+	}
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	return merged
+}
+
+// nestTags replaces the IDs of the tags sent for an object of endpoint by
+// the nested tags returned by Nautobot, or returns the validation errors.
+// Like Django REST framework, it ignores the tags of objects without tags.
+func (f *fakeNautobot) nestTags(endpoint string, obj map[string]interface{}) map[string]interface{} {
+	v, ok := obj["tags"]
+	if !ok {
+		return nil
+	}
+	if !fakeModels[endpoint].taggable {
+		delete(obj, "tags")
+		return nil
+	}
+
+	ids, ok := v.([]interface{})
+	if !ok {
+		return map[string]interface{}{"tags": []string{fmt.Sprintf(`Expected a list of items but got type "%T".`, v)}}
+	}
+	tags := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		tag := f.find("extras/tags", fakeString(id))
+		if tag == nil {
+			return map[string]interface{}{"tags": []string{fmt.Sprintf("Related object not found using the provided ID: %s", id)}}
+		}
+		// Nautobot 2.x only nests the ID of related objects by default.
+		if f.major() >= 2 {
+			tags = append(tags, map[string]interface{}{"id": tag["id"], "object_type": "extras.tag", "url": tag["url"]})
+			continue
+		}
+		tags = append(tags, map[string]interface{}{
+			"id":      tag["id"],
+			"name":    tag["name"],
+			"slug":    tag["slug"],
+			"color":   tag["color"],
+			"display": tag["display"],
+			"url":     tag["url"],
+		})
+	}
+	obj["tags"] = tags
+	return nil
+}
+
+func fakeDisplay(obj map[string]interface{}) string {
+	if name, ok := obj["name"]; ok {
+		return fakeString(name)
+	}
+	return fakeString(obj["label"])
+}
+
+func setDefault(obj map[string]interface{}, k string, v interface{}) {
+	if cur, ok := obj[k]; !ok || cur == nil || cur == "" {
+		obj[k] = v
+	}
+}
+
+var slugRegexp = regexp.MustCompile(`[^a-z0-9_]+`)
+
+func slugify(v interface{}) string {
+	return strings.Trim(slugRegexp.ReplaceAllString(strings.ToLower(fakeString(v)), "-"), "-")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
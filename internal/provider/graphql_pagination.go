@@ -0,0 +1,275 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// graphQLPagination describes how to split a GraphQL query in pages, with the
+// limit and offset arguments of its root list field.
+type graphQLPagination struct {
+	// Field is the root list field, as named in the data.
+	Field string
+	// PageSize is the number of objects per page.
+	PageSize int
+	// Parallelism is the number of pages fetched at once.
+	Parallelism int
+}
+
+// runPaginatedGraphQL runs the GraphQL request r page by page, until a page
+// holds less than p.PageSize objects, and returns a response body whose data
+// holds the objects of all the pages. The other root fields come from the
+// first page.
+func runPaginatedGraphQL(ctx context.Context, c *apiClient, r graphQLRequest, p graphQLPagination, failOnPartialData bool) ([]byte, diag.Diagnostics) {
+	type page struct {
+		data  map[string]interface{}
+		items []interface{}
+		diags diag.Diagnostics
+	}
+	fetch := func(offset int) page {
+		query, err := withPagination(r.Query, p.Field, p.PageSize, offset)
+		if err != nil {
+			return page{diags: diag.Errorf("failed to paginate GraphQL query: %s", err.Error())}
+		}
+		body, diags := runGraphQL(ctx, c, graphQLRequest{
+			Query:         query,
+			Variables:     r.Variables,
+			OperationName: r.OperationName,
+		}, failOnPartialData)
+		if diags.HasError() {
+			return page{diags: diags}
+		}
+
+		var rsp struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		d := json.NewDecoder(bytes.NewReader(body))
+		// Keep the numbers as they are.
+		d.UseNumber()
+		if err := d.Decode(&rsp); err != nil {
+			return page{diags: append(diags, diag.Errorf("failed to decode GraphQL response from %s: %s", c.Server, err.Error())...)}
+		}
+		items, ok := rsp.Data[p.Field].([]interface{})
+		if !ok {
+			return page{diags: append(diags, diag.Errorf("failed to paginate GraphQL query: %s isn't a list in the data returned by Nautobot", p.Field)...)}
+		}
+		// Pages would never end otherwise.
+		if len(items) > p.PageSize {
+			return page{diags: append(diags, diag.Errorf("failed to paginate GraphQL query: Nautobot returned %d objects in %s, more than the page size", len(items), p.Field)...)}
+		}
+		return page{data: rsp.Data, items: items, diags: diags}
+	}
+
+	var data map[string]interface{}
+	items := []interface{}{}
+	var diags diag.Diagnostics
+	for offset, done := 0, false; !done; offset += p.PageSize * p.Parallelism {
+		pages := make([]page, p.Parallelism)
+		var wg sync.WaitGroup
+		for i := range pages {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				pages[i] = fetch(offset + i*p.PageSize)
+			}(i)
+		}
+		wg.Wait()
+
+		for _, page := range pages {
+			diags = append(diags, page.diags...)
+			if diags.HasError() {
+				return nil, diags
+			}
+			if data == nil {
+				data = page.data
+			}
+			items = append(items, page.items...)
+			// The pages fetched after a short one are empty.
+			if len(page.items) < p.PageSize {
+				done = true
+				break
+			}
+		}
+	}
+
+	data[p.Field] = items
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return nil, append(diags, diag.Errorf("failed to encode GraphQL data: %s", err.Error())...)
+	}
+	return body, diags
+}
+
+// withPagination returns query with limit and offset arguments added to its
+// root field named field, either by name or by alias, in every operation.
+func withPagination(query string, field string, limit int, offset int) (string, error) {
+	tokens, err := graphQLTokens(query)
+	if err != nil {
+		return "", err
+	}
+
+	// The positions in query where to insert text.
+	inserts := map[int]string{}
+	depth, parens := 0, 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.value {
+		case "{":
+			depth++
+			continue
+		case "}":
+			depth--
+			continue
+		case "(":
+			parens++
+			continue
+		case ")":
+			parens--
+			continue
+		}
+		if depth != 1 || parens != 0 || !t.name {
+			continue
+		}
+		// Skip directives, variables, fragment spreads and inline fragments.
+		if i > 0 {
+			switch prev := tokens[i-1].value; {
+			case prev == "@" || prev == "$" || prev == "...":
+				continue
+			case prev == "on" && i > 1 && tokens[i-2].value == "...":
+				continue
+			}
+		}
+
+		key, name := i, i
+		if i+2 < len(tokens) && tokens[i+1].value == ":" && tokens[i+2].name {
+			name = i + 2
+		}
+		i = name
+		if tokens[key].value != field {
+			continue
+		}
+
+		if name+1 < len(tokens) && tokens[name+1].value == "(" {
+			for j, level := name+2, 1; j < len(tokens) && level > 0; j++ {
+				switch tokens[j].value {
+				case "(":
+					level++
+				case ")":
+					level--
+				case "limit", "offset":
+					if level == 1 && j+1 < len(tokens) && tokens[j+1].value == ":" {
+						return "", fmt.Errorf("%s already has a %s argument", field, tokens[j].value)
+					}
+				}
+			}
+			inserts[tokens[name+1].end] = fmt.Sprintf("limit: %d, offset: %d, ", limit, offset)
+		} else {
+			inserts[tokens[name].end] = fmt.Sprintf("(limit: %d, offset: %d)", limit, offset)
+		}
+	}
+	if len(inserts) == 0 {
+		return "", fmt.Errorf("the query has no root field %s", field)
+	}
+
+	positions := make([]int, 0, len(inserts))
+	for pos := range inserts {
+		positions = append(positions, pos)
+	}
+	sort.Ints(positions)
+
+	var b strings.Builder
+	last := 0
+	for _, pos := range positions {
+		b.WriteString(query[last:pos])
+		b.WriteString(inserts[pos])
+		last = pos
+	}
+	b.WriteString(query[last:])
+	return b.String(), nil
+}
+
+// graphQLToken is a lexical token of a GraphQL document, with its position.
+type graphQLToken struct {
+	value string
+	name  bool
+	start int
+	end   int
+}
+
+// graphQLTokens splits a GraphQL document in tokens, leaving out whitespace,
+// commas and comments. The values of strings aren't unescaped.
+func graphQLTokens(query string) ([]graphQLToken, error) {
+	isLetter := func(c byte) bool {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	}
+	isDigit := func(c byte) bool {
+		return c >= '0' && c <= '9'
+	}
+
+	var tokens []graphQLToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+			continue
+		case c == '#':
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(query[i:], `"""`):
+			i += 3
+			for i < len(query) && !strings.HasPrefix(query[i:], `"""`) {
+				if strings.HasPrefix(query[i:], `\"""`) {
+					i += 3
+				}
+				i++
+			}
+			if i >= len(query) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i += 3
+		case c == '"':
+			i++
+			for i < len(query) && query[i] != '"' && query[i] != '\n' {
+				if query[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(query) || query[i] != '"' {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+		case strings.HasPrefix(query[i:], "..."):
+			i += 3
+		case isLetter(c):
+			for i < len(query) && (isLetter(query[i]) || isDigit(query[i])) {
+				i++
+			}
+		case isDigit(c) || c == '-':
+			i++
+			for i < len(query) && (isDigit(query[i]) || strings.IndexByte(".eE+-", query[i]) >= 0) {
+				i++
+			}
+		default:
+			i++
+		}
+		tokens = append(tokens, graphQLToken{
+			value: query[start:i],
+			name:  isLetter(c),
+			start: start,
+			end:   i,
+		})
+	}
+	return tokens, nil
+}
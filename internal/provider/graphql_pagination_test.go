@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestWithPagination(t *testing.T) {
+	for _, tc := range []struct {
+		query   string
+		field   string
+		want    string
+		wantErr string
+	}{
+		{
+			query: `{ manufacturers { name } }`,
+			field: "manufacturers",
+			want:  `{ manufacturers(limit: 10, offset: 20) { name } }`,
+		},
+		{
+			query: `query Q($names: [String]) { manufacturers(name: $names) { name } tags { name } }`,
+			field: "manufacturers",
+			want:  `query Q($names: [String]) { manufacturers(limit: 10, offset: 20, name: $names) { name } tags { name } }`,
+		},
+		{
+			query: `{ vendors: manufacturers @include(if: true) { manufacturers: name } }`,
+			field: "vendors",
+			want:  `{ vendors: manufacturers(limit: 10, offset: 20) @include(if: true) { manufacturers: name } }`,
+		},
+		{
+			query: "# manufacturers\n{ tags(q: \"manufacturers {\") { name } manufacturers { name } }",
+			field: "manufacturers",
+			want:  "# manufacturers\n{ tags(q: \"manufacturers {\") { name } manufacturers(limit: 10, offset: 20) { name } }",
+		},
+		{
+			query:   `{ tags { manufacturers { name } } }`,
+			field:   "manufacturers",
+			wantErr: "the query has no root field manufacturers",
+		},
+		{
+			query:   `{ manufacturers(limit: 5) { name } }`,
+			field:   "manufacturers",
+			wantErr: "manufacturers already has a limit argument",
+		},
+		{
+			query:   `{ manufacturers(name: "Juniper) { name } }`,
+			field:   "manufacturers",
+			wantErr: "unterminated string at offset 22",
+		},
+	} {
+		got, err := withPagination(tc.query, tc.field, 10, 20)
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("%s: got error %v, want %s", tc.query, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.query, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestDataSourceGraphQLPagination(t *testing.T) {
+	f := newFakeNautobot(t)
+	var names []string
+	for i := 0; i < 7; i++ {
+		name := fmt.Sprintf("Manufacturer %d", i)
+		f.add("dcim/manufacturers", map[string]interface{}{"name": name})
+		names = append(names, fmt.Sprintf(`{"name":%q}`, name))
+	}
+	f.add("extras/tags", map[string]interface{}{"name": "Gold"})
+	meta := f.meta(t, nil)
+
+	pagination := func(pageSize int, parallelism int) tftypes.Value {
+		typ := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+			"field":       tftypes.String,
+			"page_size":   tftypes.Number,
+			"parallelism": tftypes.Number,
+		}}
+		return tftypes.NewValue(typ, map[string]tftypes.Value{
+			"field":       tftypes.NewValue(tftypes.String, "manufacturers"),
+			"page_size":   tftypes.NewValue(tftypes.Number, pageSize),
+			"parallelism": tftypes.NewValue(tftypes.Number, parallelism),
+		})
+	}
+	want := fmt.Sprintf(`{"manufacturers":[%s],"tags":[{"name":"Gold"}]}`, strings.Join(names, ","))
+
+	for _, tc := range []struct {
+		pageSize    int
+		parallelism int
+		wantPages   int
+	}{
+		{pageSize: 3, parallelism: 1, wantPages: 3},
+		{pageSize: 7, parallelism: 1, wantPages: 2},
+		{pageSize: 2, parallelism: 3, wantPages: 6},
+		{pageSize: 100, parallelism: 2, wantPages: 2},
+	} {
+		before := len(f.requestLog())
+		data, diags := readGraphQL(t, meta, map[string]tftypes.Value{
+			"query":      tftypes.NewValue(tftypes.String, `query { manufacturers { name } tags { name } }`),
+			"pagination": pagination(tc.pageSize, tc.parallelism),
+		})
+		if diags.HasError() {
+			t.Fatalf("page size %d: unexpected error %v", tc.pageSize, diags)
+		}
+		if data != want {
+			t.Errorf("page size %d: got %s, want %s", tc.pageSize, data, want)
+		}
+		if pages := len(f.requestLog()) - before; pages != tc.wantPages {
+			t.Errorf("page size %d, parallelism %d: got %d pages, want %d", tc.pageSize, tc.parallelism, pages, tc.wantPages)
+		}
+	}
+
+	if _, diags := readGraphQL(t, meta, map[string]tftypes.Value{
+		"query":      tftypes.NewValue(tftypes.String, `query { manufacturers { name } }`),
+		"pagination": pagination(0, 1),
+	}); !diags.HasError() {
+		t.Error("expected an error for a page size of 0")
+	}
+}
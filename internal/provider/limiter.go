@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/time/rate"
+)
+
+// requestLimiter caps the number of requests in flight and the rate at which
+// they are sent to Nautobot. A single limiter is created when the provider is
+// configured, so it applies to all the resources and data sources together.
+type requestLimiter struct {
+	// slots is nil when the number of concurrent requests is not limited.
+	slots chan struct{}
+	// rate is nil when the request rate is not limited.
+	rate *rate.Limiter
+}
+
+// newRequestLimiter returns a limiter allowing maxConcurrent requests in
+// flight and requestsPerSecond requests per second. Zero disables a limit.
+func newRequestLimiter(maxConcurrent int, requestsPerSecond float64) *requestLimiter {
+	l := &requestLimiter{}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	if requestsPerSecond > 0 {
+		l.rate = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+	}
+	return l
+}
+
+// acquire blocks until a request can be sent. The returned function must be
+// called once the request is done.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() {
+			once.Do(func() { <-l.slots })
+		}
+	}
+
+	if l.rate != nil {
+		if err := l.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// limitTransport sends every request through a requestLimiter.
+type limitTransport struct {
+	next    http.RoundTripper
+	limiter *requestLimiter
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	start := time.Now()
+	release, err := t.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tflog.Trace(ctx, "Nautobot API request dequeued", map[string]interface{}{
+		"method":     req.Method,
+		"url":        req.URL.String(),
+		"queue_time": time.Since(start).String(),
+	})
+
+	rsp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// The request is in flight until its response has been read.
+	rsp.Body = &releaseOnClose{ReadCloser: rsp.Body, release: release}
+	return rsp, nil
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
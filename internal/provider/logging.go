@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// httpSubsystem is the tflog subsystem of the HTTP wire logs. Its level
+	// can be set on its own with the TF_LOG_PROVIDER_NAUTOBOT_HTTP variable.
+	httpSubsystem = "http"
+
+	redacted = "<redacted>"
+)
+
+// redactedHeaders are never logged as they hold credentials.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// loggingTransport logs every request and response at the debug level, and
+// their headers and bodies at the trace level.
+type loggingTransport struct {
+	next http.RoundTripper
+	// maxBodySize is the number of bytes of the bodies that are logged, or 0
+	// to log them entirely.
+	maxBodySize int
+	// secrets are masked wherever they appear in the logs.
+	secrets []string
+	// redactedHeaders are not logged, on top of the usual credentials.
+	redactedHeaders []string
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := tflog.NewSubsystem(req.Context(), httpSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_NAUTOBOT_HTTP"))
+	for _, s := range t.secrets {
+		if s != "" {
+			ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, httpSubsystem, s)
+		}
+	}
+
+	fields := map[string]interface{}{
+		"method": req.Method,
+		"url":    req.URL.String(),
+	}
+
+	trace := map[string]interface{}{
+		"request_headers": logHeaders(req.Header, t.redactedHeaders),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(body)
+			body.Close()
+			trace["request_body"] = t.logBody(req.Header, b)
+		}
+	}
+
+	start := time.Now()
+	rsp, err := t.next.RoundTrip(req)
+	fields["latency"] = time.Since(start).String()
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.SubsystemDebug(ctx, httpSubsystem, "Nautobot API request failed", fields)
+		return nil, err
+	}
+
+	fields["status"] = rsp.StatusCode
+	fields["request_id"] = rsp.Header.Get("X-Request-Id")
+	trace["response_headers"] = logHeaders(rsp.Header, t.redactedHeaders)
+
+	b, err := io.ReadAll(rsp.Body)
+	rsp.Body.Close()
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.SubsystemDebug(ctx, httpSubsystem, "Nautobot API response could not be read", fields)
+		return nil, err
+	}
+	rsp.Body = io.NopCloser(bytes.NewReader(b))
+	trace["response_body"] = t.logBody(rsp.Header, b)
+
+	tflog.SubsystemDebug(ctx, httpSubsystem, "Nautobot API request", fields)
+	tflog.SubsystemTrace(ctx, httpSubsystem, "Nautobot API request details", fields, trace)
+
+	return rsp, nil
+}
+
+// logBody returns b as a string, truncated to maxBodySize. Binary content is
+// not logged.
+func (t *loggingTransport) logBody(h http.Header, b []byte) string {
+	ct := h.Get("Content-Type")
+	if ct != "" && !strings.Contains(ct, "json") && !strings.HasPrefix(ct, "text/") {
+		return fmt.Sprintf("<%d bytes of %s>", len(b), ct)
+	}
+	if t.maxBodySize > 0 && len(b) > t.maxBodySize {
+		return fmt.Sprintf("%s... (%d bytes truncated)", b[:t.maxBodySize], len(b)-t.maxBodySize)
+	}
+	return string(b)
+}
+
+// logHeaders returns the headers to log, with credentials and extra redacted
+// headers hidden.
+func logHeaders(h http.Header, extra []string) map[string]string {
+	headers := make(map[string]string, len(h))
+	for k := range h {
+		headers[k] = h.Get(k)
+	}
+	for _, k := range append(redactedHeaders, extra...) {
+		k = http.CanonicalHeaderKey(k)
+		if _, ok := headers[k]; ok {
+			headers[k] = redacted
+		}
+	}
+	return headers
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef01234567"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "c0ffee")
+		w.WriteHeader(http.StatusCreated)
+		// The tokens endpoint returns the token itself.
+		w.Write([]byte(`{"key":"` + token + `","description":"` + strings.Repeat("x", 100) + `"}`))
+	}))
+	defer srv.Close()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	client := &http.Client{
+		Transport: &loggingTransport{
+			next:        http.DefaultTransport,
+			maxBodySize: 64,
+			secrets:     []string{token},
+		},
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/api/users/tokens/", strings.NewReader(`{"description":"test"}`))
+	req.Header.Set("Authorization", "Token "+token)
+	rsp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+
+	// The response body can still be read after being logged.
+	var body bytes.Buffer
+	body.ReadFrom(rsp.Body)
+	if !strings.Contains(body.String(), token) {
+		t.Errorf("unexpected response body %q", body.String())
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d log entries, want 2: %v", len(entries), entries)
+	}
+
+	debug, trace := entries[0], entries[1]
+	if debug["@level"] != "debug" || debug["method"] != "POST" || debug["status"] != float64(201) || debug["request_id"] != "c0ffee" {
+		t.Errorf("unexpected debug entry: %v", debug)
+	}
+	if _, ok := debug["latency"]; !ok {
+		t.Errorf("missing latency in debug entry: %v", debug)
+	}
+	if trace["@level"] != "trace" || trace["request_body"] != `{"description":"test"}` {
+		t.Errorf("unexpected trace entry: %v", trace)
+	}
+	if got := trace["response_body"].(string); !strings.HasSuffix(got, "(103 bytes truncated)") {
+		t.Errorf("response body was not truncated: %q", got)
+	}
+	if got := trace["request_headers"].(map[string]interface{})["Authorization"]; got != redacted {
+		t.Errorf("Authorization header was not redacted: %q", got)
+	}
+
+	if strings.Contains(output.String(), token) {
+		t.Errorf("token found in the logs:\n%s", output.String())
+	}
+}
@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+)
+
+// NewMuxServer returns the provider server combining the SDKv2 provider and
+// the framework provider.
+func NewMuxServer(ctx context.Context, version string) (func() tfprotov5.ProviderServer, error) {
+	sdk := New(version)()
+
+	// The SDKv2 provider must come first, see frameworkProvider.Configure.
+	providers := []func() tfprotov5.ProviderServer{
+		sdk.GRPCProvider,
+		providerserver.NewProtocol5(NewFramework(version, sdk)()),
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, providers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return muxServer.ProviderServer, nil
+}
@@ -0,0 +1,210 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/tidwall/gjson"
+)
+
+// maxConcurrentPages is the number of pages listAll fetches at the same time.
+// The requests still go through the limiter of the provider.
+const maxConcurrentPages = 4
+
+// pageFetcher gets the page of a list endpoint starting at offset, with at
+// most limit results. A nil limit or offset must not be sent to Nautobot.
+type pageFetcher func(ctx context.Context, limit *int, offset *int) (*http.Response, []byte, error)
+
+// page is a page of a Django REST framework list response.
+type page struct {
+	// count is the total number of results, or -1 if unknown.
+	count   int
+	results []map[string]interface{}
+	// next is the limit and offset of the next page, nil for the last one.
+	next *pageRef
+}
+
+type pageRef struct {
+	limit  *int
+	offset *int
+}
+
+// listAll returns the results of all the pages of a list endpoint, so that
+// nothing is dropped past the PAGINATE_COUNT of Nautobot. pageSize is the
+// number of results requested per page, 0 lets Nautobot decide.
+//
+// Once the first page gives the total count, the other pages are fetched
+// concurrently with limit and offset. Should the list change in the
+// meantime, listAll falls back to following the next links one at a time,
+// as it does when the count is unknown.
+func listAll(ctx context.Context, action string, pageSize int, fetch pageFetcher) ([]map[string]interface{}, diag.Diagnostics) {
+	var limit *int
+	if pageSize > 0 {
+		limit = &pageSize
+	}
+
+	first, diags := fetchPage(ctx, action, fetch, limit, nil)
+	if diags != nil {
+		return nil, diags
+	}
+	if first.next == nil {
+		return first.results, nil
+	}
+
+	if n := len(first.results); first.count >= 0 && n > 0 {
+		results, ok, diags := fetchPagesConcurrently(ctx, action, fetch, first, n)
+		if diags != nil {
+			return nil, diags
+		}
+		if ok {
+			return results, nil
+		}
+		tflog.Debug(ctx, "list changed while fetching its pages, fetching them again one at a time", map[string]interface{}{
+			"action": action,
+		})
+	}
+
+	results := first.results
+	for next := first.next; next != nil; {
+		p, diags := fetchPage(ctx, action, fetch, next.limit, next.offset)
+		if diags != nil {
+			return nil, diags
+		}
+		if len(p.results) == 0 && p.next != nil {
+			return nil, diag.Errorf("failed to %s: got an empty page that is not the last one", action)
+		}
+		results = append(results, p.results...)
+		next = p.next
+	}
+
+	return results, nil
+}
+
+// fetchPagesConcurrently fetches the pages following first, which has n
+// results. It returns false if the list changed in the meantime.
+func fetchPagesConcurrently(ctx context.Context, action string, fetch pageFetcher, first *page, n int) ([]map[string]interface{}, bool, diag.Diagnostics) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var offsets []int
+	for o := n; o < first.count; o += n {
+		offsets = append(offsets, o)
+	}
+
+	pages := make([]*page, len(offsets))
+	slots := make(chan struct{}, maxConcurrentPages)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed diag.Diagnostics
+
+	for i := range offsets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			limit, offset := n, offsets[i]
+			p, diags := fetchPage(ctx, action, fetch, &limit, &offset)
+			if diags != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				// The requests canceled below fail too, only report the
+				// first error.
+				if failed == nil {
+					failed = diags
+					cancel()
+				}
+				return
+			}
+			pages[i] = p
+		}(i)
+	}
+	wg.Wait()
+
+	if failed != nil {
+		return nil, false, failed
+	}
+
+	results := first.results
+	for i, p := range pages {
+		want := n
+		if first.count-offsets[i] < n {
+			want = first.count - offsets[i]
+		}
+		if p.count != first.count || len(p.results) != want {
+			return nil, false, nil
+		}
+		results = append(results, p.results...)
+	}
+
+	return results, true, nil
+}
+
+func fetchPage(ctx context.Context, action string, fetch pageFetcher, limit *int, offset *int) (*page, diag.Diagnostics) {
+	rsp, body, err := fetch(ctx, limit, offset)
+	if err != nil {
+		return nil, diag.Errorf("failed to %s: %s", action, err.Error())
+	}
+	if diags := checkResponse(rsp, body, action, nil); diags != nil {
+		return nil, diags
+	}
+
+	p := &page{count: -1}
+	if count := gjson.GetBytes(body, "count"); count.Exists() {
+		p.count = int(count.Int())
+	}
+
+	results := gjson.GetBytes(body, "results")
+	if !results.IsArray() {
+		return nil, diag.Errorf("failed to %s: the response has no results", action)
+	}
+	p.results = make([]map[string]interface{}, 0)
+	if err := json.Unmarshal([]byte(results.Raw), &p.results); err != nil {
+		return nil, diag.Errorf("failed to decode the results to %s: %s", action, err.Error())
+	}
+
+	if next := gjson.GetBytes(body, "next"); next.String() != "" {
+		ref, err := parseNext(next.String())
+		if err != nil {
+			return nil, diag.Errorf("failed to %s: %s", action, err.Error())
+		}
+		p.next = ref
+	}
+
+	return p, nil
+}
+
+// parseNext returns the limit and offset of the next link of a page.
+func parseNext(next string) (*pageRef, error) {
+	u, err := url.Parse(next)
+	if err != nil {
+		return nil, fmt.Errorf("invalid next page %q: %w", next, err)
+	}
+
+	ref := &pageRef{}
+	for name, dst := range map[string]**int{"limit": &ref.limit, "offset": &ref.offset} {
+		s := u.Query().Get(name)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in next page %q", name, next)
+		}
+		*dst = &v
+	}
+	if ref.offset == nil {
+		return nil, fmt.Errorf("unsupported next page %q, only limit and offset pagination is supported", next)
+	}
+
+	return ref, nil
+}
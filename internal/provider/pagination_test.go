@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// listServer serves count items with limit and offset pagination, with a
+// default page size of 10.
+func listServer(t *testing.T, count *int64, withCount bool) (*httptest.Server, *int64) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		total := int(atomic.LoadInt64(count))
+		limit, offset := 10, 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			limit, _ = strconv.Atoi(v)
+		}
+		if v := r.URL.Query().Get("offset"); v != "" {
+			offset, _ = strconv.Atoi(v)
+		}
+
+		results := make([]map[string]interface{}, 0)
+		for i := offset; i < offset+limit && i < total; i++ {
+			results = append(results, map[string]interface{}{"name": fmt.Sprintf("item-%03d", i)})
+		}
+		body := map[string]interface{}{"results": results, "next": nil}
+		if withCount {
+			body["count"] = total
+		}
+		if offset+limit < total {
+			body["next"] = fmt.Sprintf("http://%s%s?limit=%d&offset=%d", r.Host, r.URL.Path, limit, offset+limit)
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func fetcher(srv *httptest.Server, onRequest func()) pageFetcher {
+	return func(ctx context.Context, limit *int, offset *int) (*http.Response, []byte, error) {
+		u := srv.URL + "/api/dcim/manufacturers/?"
+		if limit != nil {
+			u += fmt.Sprintf("limit=%d&", *limit)
+		}
+		if offset != nil {
+			u += fmt.Sprintf("offset=%d", *offset)
+		}
+		if onRequest != nil {
+			onRequest()
+		}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		rsp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rsp.Body.Close()
+		var body json.RawMessage
+		if err := json.NewDecoder(rsp.Body).Decode(&body); err != nil {
+			return rsp, nil, nil
+		}
+		return rsp, body, nil
+	}
+}
+
+func TestListAll(t *testing.T) {
+	cases := []struct {
+		name         string
+		count        int64
+		withCount    bool
+		pageSize     int
+		wantRequests int64
+	}{
+		{name: "single page", count: 5, withCount: true, wantRequests: 1},
+		{name: "empty", count: 0, withCount: true, wantRequests: 1},
+		{name: "default page size", count: 95, withCount: true, wantRequests: 10},
+		{name: "page size", count: 95, withCount: true, pageSize: 50, wantRequests: 2},
+		{name: "exact", count: 100, withCount: true, pageSize: 25, wantRequests: 4},
+		{name: "without count", count: 95, pageSize: 20, wantRequests: 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			count := tc.count
+			srv, requests := listServer(t, &count, tc.withCount)
+
+			results, diags := listAll(context.Background(), "list manufacturers", tc.pageSize, fetcher(srv, nil))
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+			if int64(len(results)) != tc.count {
+				t.Fatalf("got %d results, want %d", len(results), tc.count)
+			}
+			for i, r := range results {
+				if want := fmt.Sprintf("item-%03d", i); r["name"] != want {
+					t.Fatalf("got %v at %d, want %s", r["name"], i, want)
+				}
+			}
+			if *requests != tc.wantRequests {
+				t.Errorf("got %d requests, want %d", *requests, tc.wantRequests)
+			}
+		})
+	}
+}
+
+func TestListAllConcurrency(t *testing.T) {
+	count := int64(100)
+	srv, _ := listServer(t, &count, true)
+
+	var mu sync.Mutex
+	var current, peak int
+	fetch := fetcher(srv, nil)
+	results, diags := listAll(context.Background(), "list manufacturers", 5, func(ctx context.Context, limit *int, offset *int) (*http.Response, []byte, error) {
+		mu.Lock()
+		current++
+		if current > peak {
+			peak = current
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			current--
+			mu.Unlock()
+		}()
+		return fetch(ctx, limit, offset)
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(results) != 100 {
+		t.Errorf("got %d results", len(results))
+	}
+	if peak > maxConcurrentPages {
+		t.Errorf("got %d concurrent requests, want at most %d", peak, maxConcurrentPages)
+	}
+}
+
+func TestListAllChanged(t *testing.T) {
+	count := int64(30)
+	srv, _ := listServer(t, &count, true)
+
+	// An item is added once the first page has been read, the pages are
+	// then read again one at a time.
+	var calls int64
+	results, diags := listAll(context.Background(), "list manufacturers", 10, fetcher(srv, func() {
+		if atomic.AddInt64(&calls, 1) == 2 {
+			atomic.StoreInt64(&count, 31)
+		}
+	}))
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(results) != 31 {
+		t.Errorf("got %d results, want 31", len(results))
+	}
+}
+
+func TestListAllError(t *testing.T) {
+	count := int64(100)
+	srv, _ := listServer(t, &count, true)
+
+	fetch := fetcher(srv, nil)
+	_, diags := listAll(context.Background(), "list manufacturers", 10, func(ctx context.Context, limit *int, offset *int) (*http.Response, []byte, error) {
+		if offset != nil && *offset == 50 {
+			return nil, nil, fmt.Errorf("boom")
+		}
+		return fetch(ctx, limit, offset)
+	})
+	if len(diags) != 1 || diags[0].Summary != "failed to list manufacturers: boom" {
+		t.Errorf("got %v", diags)
+	}
+}
+
+func TestParseNext(t *testing.T) {
+	ref, err := parseNext("https://nautobot.example.com/api/dcim/manufacturers/?limit=50&offset=100&name=x")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if *ref.limit != 50 || *ref.offset != 100 {
+		t.Errorf("got limit %d and offset %d", *ref.limit, *ref.offset)
+	}
+
+	if _, err := parseNext("https://nautobot.example.com/api/dcim/manufacturers/?cursor=abc"); err == nil {
+		t.Error("expected an error for cursor pagination")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/deepmap/oapi-codegen/pkg/types"
@@ -11,6 +12,9 @@ import (
 )
 
 func NewSecurityProviderNautobotToken(t string) (*SecurityProviderNautobotToken, error) {
+	if err := validateToken(t); err != nil {
+		return nil, err
+	}
 	return &SecurityProviderNautobotToken{
 		token: t,
 	}, nil
@@ -25,6 +29,30 @@ func (s *SecurityProviderNautobotToken) Intercept(ctx context.Context, req *http
 	return nil
 }
 
+// extraHeaders returns a request editor that adds headers to every request,
+// for example those needed by a proxy in front of Nautobot.
+func extraHeaders(headers map[string]string) nb.RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return nil
+	}
+}
+
+// extraQuery returns a request editor that adds query parameters, for the
+// filters missing from the generated client.
+func extraQuery(query url.Values) nb.RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		q := req.URL.Query()
+		for k, v := range query {
+			q[k] = v
+		}
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+}
+
 // PaginatedSiteList defines model for PaginatedSiteList.
 type PaginatedSiteList struct {
 	Count    *int    `json:"count,omitempty"`
@@ -74,7 +102,7 @@ type Site struct {
 		Label *nb.SiteStatusLabel `json:"label,omitempty"`
 		Value *nb.SiteStatusValue `json:"value,omitempty"`
 	} `json:"status"`
-	Tags   *[]nb.TagSerializerField `json:"tags,omitempty"`
+	Tags   *[]nestedTag `json:"tags,omitempty"`
 	Tenant *struct {
 		// Embedded struct due to allOf(#/components/schemas/NestedTenant)
 		nb.NestedTenant `yaml:",inline"`
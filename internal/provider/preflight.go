@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/tidwall/gjson"
+
+	nb "github.com/nautobot/go-nautobot/pkg/nautobot"
+)
+
+// normalizeServerURL makes sure s points to the root of the Nautobot REST API
+// and ends with a slash, as both the generated client and the GraphQL data
+// source build their URLs by appending to it. Both "https://nautobot" and
+// "https://nautobot/api" become "https://nautobot/api/".
+func normalizeServerURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("%q is not an absolute URL", s)
+	}
+
+	p := strings.TrimRight(u.Path, "/")
+	if !strings.HasSuffix(p, "/api") {
+		p += "/api"
+	}
+	u.Path = p + "/"
+	u.RawPath = ""
+
+	return u.String(), nil
+}
+
+// preflight makes sure that Nautobot can be reached at s and accepts token,
+// so that configuration errors are reported when the provider is configured
+// rather than as obscure errors in the middle of a refresh. Write access is
+// not checked when the provider is read-only. It returns the version of
+// Nautobot.
+func preflight(ctx context.Context, c *nb.ClientWithResponses, s string, apiVersion string, token string, readOnly bool) (*version.Version, diag.Diagnostics) {
+	rsp, err := c.StatusRetrieveWithResponse(ctx)
+	if err != nil {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Nautobot is unreachable",
+			Detail:   fmt.Sprintf("Failed to connect to %s: %s\n\nCheck url, proxy_url and the TLS settings of the provider.", s, err.Error()),
+		}}
+	}
+
+	switch rsp.StatusCode() {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Invalid token",
+			Detail:   fmt.Sprintf("%s rejected the token: %s", s, errorDetail(rsp.Body, rsp.Status())),
+		}}
+	case http.StatusOK:
+		if !gjson.GetBytes(rsp.Body, "nautobot-version").Exists() {
+			return nil, notNautobot(s, rsp.Status())
+		}
+	case http.StatusNotAcceptable:
+	default:
+		return nil, notNautobot(s, rsp.Status())
+	}
+
+	v, diags := checkServerCompatibility(ctx, rsp, s, apiVersion)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	if readOnly {
+		return v, nil
+	}
+	return v, checkTokenWriteAccess(ctx, c, s, token)
+}
+
+// checkTokenWriteAccess warns when the token can only be used to read data.
+func checkTokenWriteAccess(ctx context.Context, c *nb.ClientWithResponses, s string, token string) diag.Diagnostics {
+	// Users can only list their own tokens. Filtering on the key would put it
+	// in the URL, so look for it in the results instead.
+	rsp, err := c.UsersTokensListWithResponse(ctx, &nb.UsersTokensListParams{})
+	if err != nil || rsp.StatusCode() != http.StatusOK {
+		// Not being able to list tokens doesn't prevent managing resources.
+		tflog.Debug(ctx, "failed to check whether the token is write-enabled")
+		return nil
+	}
+
+	writeEnabled := gjson.GetBytes(rsp.Body, fmt.Sprintf("results.#(key==%q).write_enabled", token))
+	if writeEnabled.Exists() && !writeEnabled.Bool() {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Token without write access",
+			Detail:   fmt.Sprintf("The token is not write-enabled on %s, resources can be read but not created, updated or deleted.", s),
+		}}
+	}
+
+	return nil
+}
+
+func notNautobot(s string, status string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Not a Nautobot server",
+		Detail:   fmt.Sprintf("GET %sstatus/ returned %s, %s does not look like the REST API of Nautobot. Check the url of the provider.", s, status, s),
+	}}
+}
+
+// errorDetail returns the "detail" message of a Nautobot error response, or
+// fallback if there isn't one.
+func errorDetail(body []byte, fallback string) string {
+	if d := gjson.GetBytes(body, "detail"); d.Exists() {
+		return d.String()
+	}
+	return fallback
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestNormalizeServerURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://nautobot.example.com":       "https://nautobot.example.com/api/",
+		"https://nautobot.example.com/":      "https://nautobot.example.com/api/",
+		"https://nautobot.example.com/api":   "https://nautobot.example.com/api/",
+		"https://nautobot.example.com/api/":  "https://nautobot.example.com/api/",
+		"https://nautobot.example.com/api//": "https://nautobot.example.com/api/",
+		"https://example.com/nautobot":       "https://example.com/nautobot/api/",
+		"https://example.com/nautobot/api/":  "https://example.com/nautobot/api/",
+		"http://localhost:8080?x=1":          "http://localhost:8080/api/?x=1",
+	} {
+		got, err := normalizeServerURL(in)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+
+	if _, err := normalizeServerURL("nautobot.example.com/api/"); err == nil {
+		t.Error("expected an error for a relative URL")
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	cases := []struct {
+		name        string
+		handler     http.HandlerFunc
+		url         string
+		skip        bool
+		wantSummary string
+		wantWarning bool
+	}{
+		{
+			name: "ok",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/status/":
+					w.Write([]byte(`{"nautobot-version":"1.5.8"}`))
+				case "/api/users/tokens/":
+					w.Write([]byte(`{"count":1,"results":[{"key":"` + testToken + `","write_enabled":true}]}`))
+				}
+			},
+		},
+		{
+			name: "read-only token",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/status/":
+					w.Write([]byte(`{"nautobot-version":"1.5.8"}`))
+				case "/api/users/tokens/":
+					w.Write([]byte(`{"count":2,"results":[{"key":"other","write_enabled":true},{"key":"` + testToken + `","write_enabled":false}]}`))
+				}
+			},
+			wantSummary: "Token without write access",
+			wantWarning: true,
+		},
+		{
+			name:        "unreachable",
+			url:         closed.URL,
+			wantSummary: "Nautobot is unreachable",
+		},
+		{
+			name: "invalid token",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"detail":"Invalid token"}`))
+			},
+			wantSummary: "Invalid token",
+		},
+		{
+			name: "not nautobot",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(`<html>It works!</html>`))
+			},
+			wantSummary: "Not a Nautobot server",
+		},
+		{
+			name:        "not found",
+			handler:     http.NotFound,
+			wantSummary: "Not a Nautobot server",
+		},
+		{
+			name:    "skipped",
+			handler: http.NotFound,
+			skip:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := tc.url
+			if tc.handler != nil {
+				srv := httptest.NewServer(tc.handler)
+				defer srv.Close()
+				url = srv.URL
+			}
+
+			config := map[string]interface{}{
+				// The provider adds the missing /api/.
+				"url":            url,
+				"token":          testToken,
+				"max_retries":    0,
+				"skip_preflight": tc.skip,
+			}
+			if tc.skip {
+				config["api_version"] = "2.0"
+			}
+			p := New("dev")()
+			diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(config))
+
+			if tc.wantSummary == "" {
+				if len(diags) > 0 {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				if got := p.Meta().(*apiClient).Server; got != url+"/api/" {
+					t.Errorf("got server %s", got)
+				}
+				return
+			}
+			if len(diags) != 1 || diags[0].Summary != tc.wantSummary {
+				t.Fatalf("got %v, want %q", diags, tc.wantSummary)
+			}
+			if want := map[bool]diag.Severity{true: diag.Warning, false: diag.Error}[tc.wantWarning]; diags[0].Severity != want {
+				t.Errorf("got severity %v, want %v", diags[0].Severity, want)
+			}
+		})
+	}
+}
@@ -3,7 +3,13 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -38,11 +44,11 @@ func New(version string) func() *schema.Provider {
 						nil,
 					),
 					ValidateFunc: validation.IsURLWithHTTPorHTTPS,
-					Description:  "Nautobot API URL",
+					Description:  "Nautobot API URL, such as `https://nautobot.example.com/api/`. The `/api/` suffix is added when missing.",
 				},
 				"token": {
 					Type:      schema.TypeString,
-					Required:  true,
+					Optional:  true,
 					Sensitive: true,
 					DefaultFunc: schema.EnvDefaultFunc(
 						"NAUTOBOT_TOKEN",
@@ -50,10 +56,161 @@ func New(version string) func() *schema.Provider {
 					),
 					Description: "Admin API token",
 				},
+				"token_file": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("NAUTOBOT_TOKEN_FILE", nil),
+					Description: "Path to a file holding the API token, used when `token` is not set. Leading and trailing whitespace is ignored.",
+				},
+				"token_command": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					MinItems:    1,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Command printing the API token on its standard output, such as a credential helper, used when neither `token` nor `token_file` are set. The first element is the program to run and the others are its arguments. It runs once per Terraform run.",
+				},
+				"api_version": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.EnvDefaultFunc("NAUTOBOT_API_VERSION", nil),
+					ValidateDiagFunc: validateAPIVersion,
+					Description:      fmt.Sprintf("Nautobot REST API version to request, such as `1.3` or `2.0`. Defaults to `%s` for Nautobot 1.x and `%s` for Nautobot 2.x. Required when `skip_preflight` is set, as the version of Nautobot is then unknown.", defaultAPIVersions[1], defaultAPIVersions[2]),
+				},
+				"proxy_url": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("NAUTOBOT_PROXY_URL", nil),
+					ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+					Description:  "URL of the proxy used to reach Nautobot. Defaults to the proxy set in the `HTTPS_PROXY`, `HTTP_PROXY` and `NO_PROXY` environment variables.",
+				},
+				"headers": {
+					Type:        schema.TypeMap,
+					Optional:    true,
+					Sensitive:   true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Extra headers sent with every request, such as those required by an identity-aware proxy. They can't override the `Authorization` and `Accept` headers set by the provider.",
+				},
+				"read_only": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("NAUTOBOT_READ_ONLY", false),
+					Description: "Refuse to create, update or delete anything in Nautobot, whatever the permissions of the token. Data sources and refreshes keep working.",
+				},
+				"on_conflict": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("NAUTOBOT_ON_CONFLICT", conflictError),
+					ValidateFunc: validation.StringInSlice(conflictModes, false),
+					Description:  "What resources do when the object they create already exists in Nautobot: `error`, `adopt` to manage the existing object and update it to the configuration, or `adopt_if_matching` to only manage it if its attributes match the configuration. Resources can override it with their own `on_conflict`. Adoptions are reported as warnings.",
+				},
+				"skip_preflight": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("NAUTOBOT_SKIP_PREFLIGHT", false),
+					Description: "Skip the checks made when the provider is configured, which make sure Nautobot can be reached, runs a supported version and accepts the token. `api_version` must then be set.",
+				},
+				"max_retries": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      4,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "Maximum number of times a request is retried after a transient failure. Set to `0` to disable retries.",
+				},
+				"retry_wait_min": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "1s",
+					ValidateDiagFunc: validateDuration,
+					Description:      "Minimum time to wait before retrying a request, as a duration such as `500ms` or `2s`.",
+				},
+				"retry_wait_max": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "30s",
+					ValidateDiagFunc: validateDuration,
+					Description:      "Maximum time to wait before retrying a request, as a duration such as `30s` or `1m`. Longer `Retry-After` headers sent by the server are capped to it.",
+				},
+				"request_timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.EnvDefaultFunc("NAUTOBOT_REQUEST_TIMEOUT", "60s"),
+					ValidateDiagFunc: validateDuration,
+					Description:      "Time to wait for Nautobot to answer a request before giving up, as a duration such as `60s` or `5m`. Requests that time out are retried when it is safe. Set to `0s` to wait indefinitely.",
+				},
+				"max_concurrent_requests": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      0,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "Maximum number of requests sent to Nautobot at the same time, across all resources and data sources. Defaults to `0`, which means no limit.",
+				},
+				"requests_per_second": {
+					Type:         schema.TypeFloat,
+					Optional:     true,
+					Default:      0.0,
+					ValidateFunc: validation.FloatAtLeast(0),
+					Description:  "Maximum number of requests sent to Nautobot per second, across all resources and data sources. Defaults to `0`, which means no limit.",
+				},
+				"http_log_max_body_size": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      8192,
+					ValidateFunc: validation.IntAtLeast(0),
+					Description:  "Number of bytes of the request and response bodies written to the HTTP trace logs. Set to `0` to log the whole bodies.",
+				},
+				"ca_cert_file": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.EnvDefaultFunc("NAUTOBOT_CA_CERT_FILE", nil),
+					ConflictsWith: []string{"ca_cert_pem"},
+					Description:   "Path to a PEM-encoded CA bundle used to verify the Nautobot server certificate.",
+				},
+				"ca_cert_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.EnvDefaultFunc("NAUTOBOT_CA_CERT_PEM", nil),
+					ConflictsWith: []string{"ca_cert_file"},
+					Description:   "PEM-encoded CA bundle used to verify the Nautobot server certificate.",
+				},
+				"client_cert_file": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.EnvDefaultFunc("NAUTOBOT_CLIENT_CERT_FILE", nil),
+					ConflictsWith: []string{"client_cert_pem"},
+					Description:   "Path to a PEM-encoded client certificate for mutual TLS.",
+				},
+				"client_cert_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.EnvDefaultFunc("NAUTOBOT_CLIENT_CERT_PEM", nil),
+					ConflictsWith: []string{"client_cert_file"},
+					Description:   "PEM-encoded client certificate for mutual TLS.",
+				},
+				"client_key_file": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					DefaultFunc:   schema.EnvDefaultFunc("NAUTOBOT_CLIENT_KEY_FILE", nil),
+					ConflictsWith: []string{"client_key_pem"},
+					Description:   "Path to the PEM-encoded private key of the client certificate.",
+				},
+				"client_key_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					Sensitive:     true,
+					DefaultFunc:   schema.EnvDefaultFunc("NAUTOBOT_CLIENT_KEY_PEM", nil),
+					ConflictsWith: []string{"client_key_file"},
+					Description:   "PEM-encoded private key of the client certificate.",
+				},
+				"insecure_skip_verify": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("NAUTOBOT_INSECURE_SKIP_VERIFY", false),
+					Description: "Skip the verification of the Nautobot server certificate. Only use this for testing.",
+				},
 			},
 			DataSourcesMap: map[string]*schema.Resource{
+				"nautobot_manufacturer":  dataSourceManufacturer(),
 				"nautobot_manufacturers": dataSourceManufacturers(),
-				"nautobot_graphql":       dataSourceGraphQL(),
 			},
 			ResourcesMap: map[string]*schema.Resource{
 				"nautobot_manufacturer": resourceManufacturer(),
@@ -72,8 +229,27 @@ func New(version string) func() *schema.Provider {
 type apiClient struct {
 	Client     *nb.ClientWithResponses
 	Server     string
-	Token      *SecurityProviderNautobotToken
 	BaseClient *nb.Client
+	ReadOnly   bool
+	// OnConflict is the default on_conflict setting of the resources.
+	OnConflict string
+	// APIVersion is the REST API version requested, its major version is
+	// the one of Nautobot.
+	APIVersion string
+
+	// customFieldTypes caches the types of the custom fields by content
+	// type and key.
+	customFieldsMu   sync.Mutex
+	customFieldTypes map[string]map[string]string
+
+	// tagList caches the tags of Nautobot.
+	tagsMu  sync.Mutex
+	tagList []nestedTag
+}
+
+// MajorVersion returns the major version of Nautobot.
+func (c *apiClient) MajorVersion() int {
+	return majorVersion(c.APIVersion)
 }
 
 func configure(
@@ -81,24 +257,118 @@ func configure(
 	p *schema.Provider,
 ) func(context.Context, *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	return func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-		serverURL := d.Get("url").(string)
-		_, hasToken := d.GetOk("token")
-
-		var diags diag.Diagnostics = nil
+		serverURL, err := normalizeServerURL(d.Get("url").(string))
+		if err != nil {
+			return &apiClient{Server: d.Get("url").(string)}, diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "Invalid url",
+				Detail:        err.Error(),
+				AttributePath: cty.GetAttrPath("url"),
+			}}
+		}
 
-		if !hasToken {
-			diags = diag.FromErr(fmt.Errorf("missing token"))
-			diags[0].Severity = diag.Error
+		t, source, diags := providerToken(ctx, d)
+		if diags.HasError() {
 			return &apiClient{Server: serverURL}, diags
 		}
 
-		token, _ := NewSecurityProviderNautobotToken(
-			d.Get("token").(string),
+		token, err := NewSecurityProviderNautobotToken(t)
+		if err != nil {
+			return &apiClient{Server: serverURL}, append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid token",
+				Detail:        fmt.Sprintf("The token from %s is not a valid Nautobot API token: %s.", source, err),
+				AttributePath: cty.GetAttrPath(source),
+			})
+		}
+
+		// Durations have already been validated by validateDuration.
+		waitMin, _ := time.ParseDuration(d.Get("retry_wait_min").(string))
+		waitMax, _ := time.ParseDuration(d.Get("retry_wait_max").(string))
+		requestTimeout, _ := time.ParseDuration(d.Get("request_timeout").(string))
+		if waitMin > waitMax {
+			return &apiClient{Server: serverURL}, diag.Errorf("retry_wait_min (%s) must not be greater than retry_wait_max (%s)", waitMin, waitMax)
+		}
+
+		tlsConfig, err := tlsSettings{
+			CACertFile:         d.Get("ca_cert_file").(string),
+			CACertPEM:          d.Get("ca_cert_pem").(string),
+			ClientCertFile:     d.Get("client_cert_file").(string),
+			ClientKeyFile:      d.Get("client_key_file").(string),
+			ClientCertPEM:      d.Get("client_cert_pem").(string),
+			ClientKeyPEM:       d.Get("client_key_pem").(string),
+			InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+		}.tlsConfig()
+		if err != nil {
+			return &apiClient{Server: serverURL}, diag.FromErr(err)
+		}
+		if tlsConfig != nil && tlsConfig.InsecureSkipVerify {
+			tflog.Warn(ctx, "TLS certificate verification is disabled for Nautobot")
+		}
+
+		// The transport is shared by Client and BaseClient, so that the raw
+		// requests made with BaseClient get the same behavior.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		if proxyURL, ok := d.GetOk("proxy_url"); ok {
+			u, err := url.Parse(proxyURL.(string))
+			if err != nil {
+				return &apiClient{Server: serverURL}, diag.Errorf("failed to parse proxy_url: %s", err.Error())
+			}
+			transport.Proxy = http.ProxyURL(u)
+		}
+
+		headers := make(map[string]string)
+		for k, v := range d.Get("headers").(map[string]interface{}) {
+			headers[k] = v.(string)
+		}
+		headerNames := make([]string, 0, len(headers))
+		for k := range headers {
+			headerNames = append(headerNames, k)
+		}
+
+		// Retries go through the limiter as well.
+		limiter := newRequestLimiter(
+			d.Get("max_concurrent_requests").(int),
+			d.Get("requests_per_second").(float64),
+		)
+
+		// Every attempt is logged, without the time spent in the limiter.
+		logging := &loggingTransport{
+			next:        transport,
+			maxBodySize: d.Get("http_log_max_body_size").(int),
+			secrets:     []string{token.token},
+			// The extra headers may hold credentials.
+			redactedHeaders: headerNames,
+		}
+
+		var rt http.RoundTripper = newRetryTransport(
+			&limitTransport{
+				next:    &timeoutTransport{next: logging, timeout: requestTimeout},
+				limiter: limiter,
+			},
+			d.Get("max_retries").(int),
+			waitMin,
+			waitMax,
 		)
 
+		readOnly := d.Get("read_only").(bool)
+		if readOnly {
+			rt = &readOnlyTransport{next: rt, graphqlURL: fmt.Sprintf("%sgraphql/", serverURL)}
+		}
+
+		httpClient := &http.Client{Transport: rt}
+
+		// Until the version of Nautobot is known, requests ask for its default
+		// REST API version if api_version is not set.
+		apiVersion := d.Get("api_version").(string)
+
 		c, err := nb.NewClientWithResponses(
 			serverURL,
+			nb.WithHTTPClient(httpClient),
+			nb.WithRequestEditorFn(extraHeaders(headers)),
 			nb.WithRequestEditorFn(token.Intercept),
+			nb.WithRequestEditorFn(acceptAPIVersion(&apiVersion)),
 		)
 		if err != nil {
 			diags = diag.FromErr(err)
@@ -107,7 +377,10 @@ func configure(
 		}
 		bc, err := nb.NewClient(
 			serverURL,
+			nb.WithHTTPClient(httpClient),
+			nb.WithRequestEditorFn(extraHeaders(headers)),
 			nb.WithRequestEditorFn(token.Intercept),
+			nb.WithRequestEditorFn(acceptAPIVersion(&apiVersion)),
 		)
 		if err != nil {
 			diags = diag.FromErr(err)
@@ -115,11 +388,59 @@ func configure(
 			return &apiClient{Server: serverURL}, diags
 		}
 
+		if d.Get("skip_preflight").(bool) {
+			// The version of Nautobot, and so the API version it accepts,
+			// is only known from the preflight checks.
+			if apiVersion == "" {
+				return &apiClient{Server: serverURL}, append(diags, diag.Diagnostic{
+					Severity:      diag.Error,
+					Summary:       "Missing api_version",
+					Detail:        "api_version must be set with skip_preflight, as the version of Nautobot is then unknown. Set it to the REST API version to request, such as 1.3 for Nautobot 1.x or 2.0 for Nautobot 2.x.",
+					AttributePath: cty.GetAttrPath("api_version"),
+				})
+			}
+		} else {
+			v, pdiags := preflight(ctx, c, serverURL, apiVersion, t, readOnly)
+			diags = append(diags, pdiags...)
+			if diags.HasError() {
+				return &apiClient{Server: serverURL}, diags
+			}
+			if apiVersion == "" {
+				apiVersion = defaultAPIVersions[v.Segments()[0]]
+			}
+		}
+
 		return &apiClient{
 			Client:     c,
 			Server:     serverURL,
-			Token:      token,
 			BaseClient: bc,
+			ReadOnly:   readOnly,
+			OnConflict: d.Get("on_conflict").(string),
+			APIVersion: apiVersion,
 		}, diags
 	}
 }
+
+func validateDate(v interface{}, path cty.Path) diag.Diagnostics {
+	if _, err := time.Parse("2006-01-02", v.(string)); err != nil {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Invalid date",
+			Detail:        fmt.Sprintf("%q is not a valid date such as 2023-01-31", v),
+			AttributePath: path,
+		}}
+	}
+	return nil
+}
+
+func validateDuration(v interface{}, path cty.Path) diag.Diagnostics {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Invalid duration",
+			Detail:        fmt.Sprintf("%q is not a valid duration: %s", v, err),
+			AttributePath: path,
+		}}
+	}
+	return nil
+}
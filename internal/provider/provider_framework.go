@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	fwschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var _ provider.Provider = &frameworkProvider{}
+
+// frameworkProvider is the terraform-plugin-framework half of the provider.
+// It is muxed with the SDKv2 provider returned by New, which still serves the
+// existing resources and data sources, while new ones are written with the
+// framework.
+//
+// It shares the configuration of the SDKv2 provider: it declares the same
+// schema and reuses the apiClient built by configure, so that both halves
+// send their requests through the same transport and limiter.
+type frameworkProvider struct {
+	version string
+	sdk     *schema.Provider
+}
+
+// NewFramework returns the framework provider muxed with sdk, which must be
+// served before it so that it gets configured first.
+func NewFramework(version string, sdk *schema.Provider) func() provider.Provider {
+	return func() provider.Provider {
+		return &frameworkProvider{
+			version: version,
+			sdk:     sdk,
+		}
+	}
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "nautobot"
+	resp.Version = p.version
+}
+
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	s, err := frameworkProviderSchema(p.sdk.Schema)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to build the provider schema", err.Error())
+		return
+	}
+	resp.Schema = s
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	// The muxed servers are configured one at a time, in order, and the SDKv2
+	// provider comes first.
+	c, ok := p.sdk.Meta().(*apiClient)
+	if !ok || c == nil {
+		resp.Diagnostics.AddError(
+			"Provider not configured",
+			"The SDKv2 provider must be configured before the framework provider. This is a bug in the provider.",
+		)
+		return
+	}
+
+	resp.DataSourceData = c
+	resp.ResourceData = c
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{}
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		NewGraphQLDataSource,
+	}
+}
+
+// frameworkProviderSchema converts the schema of the SDKv2 provider, as the
+// muxed servers must declare identical provider schemas.
+func frameworkProviderSchema(sdk map[string]*schema.Schema) (fwschema.Schema, error) {
+	attributes := make(map[string]fwschema.Attribute, len(sdk))
+
+	for name, s := range sdk {
+		required, optional := sdkRequiredness(s)
+
+		switch s.Type {
+		case schema.TypeString:
+			attributes[name] = fwschema.StringAttribute{
+				MarkdownDescription: s.Description,
+				Required:            required,
+				Optional:            optional,
+				Sensitive:           s.Sensitive,
+			}
+		case schema.TypeBool:
+			attributes[name] = fwschema.BoolAttribute{
+				MarkdownDescription: s.Description,
+				Required:            required,
+				Optional:            optional,
+				Sensitive:           s.Sensitive,
+			}
+		case schema.TypeInt:
+			attributes[name] = fwschema.Int64Attribute{
+				MarkdownDescription: s.Description,
+				Required:            required,
+				Optional:            optional,
+				Sensitive:           s.Sensitive,
+			}
+		case schema.TypeFloat:
+			attributes[name] = fwschema.Float64Attribute{
+				MarkdownDescription: s.Description,
+				Required:            required,
+				Optional:            optional,
+				Sensitive:           s.Sensitive,
+			}
+		case schema.TypeList:
+			if elem, ok := s.Elem.(*schema.Schema); !ok || elem.Type != schema.TypeString {
+				return fwschema.Schema{}, fmt.Errorf("%s: only lists of strings are supported", name)
+			}
+			attributes[name] = fwschema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: s.Description,
+				Required:            required,
+				Optional:            optional,
+				Sensitive:           s.Sensitive,
+			}
+		case schema.TypeMap:
+			if elem, ok := s.Elem.(*schema.Schema); !ok || elem.Type != schema.TypeString {
+				return fwschema.Schema{}, fmt.Errorf("%s: only maps of strings are supported", name)
+			}
+			attributes[name] = fwschema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: s.Description,
+				Required:            required,
+				Optional:            optional,
+				Sensitive:           s.Sensitive,
+			}
+		default:
+			return fwschema.Schema{}, fmt.Errorf("%s: unsupported type %s", name, s.Type)
+		}
+	}
+
+	return fwschema.Schema{Attributes: attributes}, nil
+}
+
+// sdkRequiredness returns whether s is reported as required or optional by the
+// SDK. Required arguments with a DefaultFunc become optional when the function
+// returns a value, for example url when NAUTOBOT_URL is set.
+func sdkRequiredness(s *schema.Schema) (bool, bool) {
+	if s.Required && s.DefaultFunc != nil {
+		if v, err := s.DefaultFunc(); err != nil || v != nil {
+			return false, true
+		}
+	}
+	return s.Required, s.Optional
+}
+
+// frameworkDiagnostics converts the SDKv2 diagnostics returned by the helpers
+// shared by both halves of the provider.
+func frameworkDiagnostics(diags diag.Diagnostics) fwdiag.Diagnostics {
+	var out fwdiag.Diagnostics
+	for _, d := range diags {
+		var p path.Path
+		for _, step := range d.AttributePath {
+			switch step := step.(type) {
+			case cty.GetAttrStep:
+				p = p.AtName(step.Name)
+			case cty.IndexStep:
+				switch step.Key.Type() {
+				case cty.String:
+					p = p.AtMapKey(step.Key.AsString())
+				case cty.Number:
+					i, _ := step.Key.AsBigFloat().Int64()
+					p = p.AtListIndex(int(i))
+				}
+			}
+		}
+
+		switch {
+		case d.Severity == diag.Error && len(d.AttributePath) > 0:
+			out.AddAttributeError(p, d.Summary, d.Detail)
+		case d.Severity == diag.Error:
+			out.AddError(d.Summary, d.Detail)
+		case len(d.AttributePath) > 0:
+			out.AddAttributeWarning(p, d.Summary, d.Detail)
+		default:
+			out.AddWarning(d.Summary, d.Detail)
+		}
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// readDataSource runs the Read of the framework data source ds, configured
+// with meta, with the attributes in config, the others being null.
+func readDataSource(t *testing.T, ds datasource.DataSource, meta interface{}, config map[string]tftypes.Value) (tfsdk.State, fwdiag.Diagnostics) {
+	t.Helper()
+	ctx := context.Background()
+
+	if c, ok := ds.(datasource.DataSourceWithConfigure); ok {
+		var resp datasource.ConfigureResponse
+		c.Configure(ctx, datasource.ConfigureRequest{ProviderData: meta}, &resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("failed to configure the data source: %v", resp.Diagnostics)
+		}
+	}
+
+	var schemaResp datasource.SchemaResponse
+	ds.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("failed to get the data source schema: %v", schemaResp.Diagnostics)
+	}
+	typ := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	values := map[string]tftypes.Value{}
+	for k, at := range typ.AttributeTypes {
+		values[k] = tftypes.NewValue(at, nil)
+		if v, ok := config[k]; ok {
+			values[k] = v
+		}
+	}
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(typ, values)},
+	}
+	resp := datasource.ReadResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(typ, nil)},
+	}
+	ds.Read(ctx, req, &resp)
+
+	return resp.State, resp.Diagnostics
+}
+
+func TestFrameworkDiagnostics(t *testing.T) {
+	got := frameworkDiagnostics(diag.Diagnostics{
+		{Severity: diag.Error, Summary: "Failed", Detail: "detail"},
+		{Severity: diag.Error, Summary: "Invalid", AttributePath: cty.GetAttrPath("custom_fields").IndexString("rack_units")},
+		{Severity: diag.Warning, Summary: "Careful", AttributePath: cty.GetAttrPath("tags").IndexInt(1)},
+	})
+
+	want := fwdiag.Diagnostics{}
+	want.AddError("Failed", "detail")
+	want.AddAttributeError(path.Root("custom_fields").AtMapKey("rack_units"), "Invalid", "")
+	want.AddAttributeWarning(path.Root("tags").AtListIndex(1), "Careful", "")
+	if !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -1,17 +1,31 @@
 package provider
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/tidwall/gjson"
 )
 
 // providerFactories are used to instantiate a provider during acceptance testing.
 // The factory function will be invoked for every Terraform CLI command executed
 // to create a provider server to which the CLI can reattach.
-var providerFactories = map[string]func() (*schema.Provider, error){
-	"nautobot": func() (*schema.Provider, error) {
-		return New("dev")(), nil
+var providerFactories = map[string]func() (tfprotov5.ProviderServer, error){
+	"nautobot": func() (tfprotov5.ProviderServer, error) {
+		server, err := NewMuxServer(context.Background(), "dev")
+		if err != nil {
+			return nil, err
+		}
+		return server(), nil
 	},
 }
 
@@ -21,8 +35,115 @@ func TestProvider(t *testing.T) {
 	}
 }
 
+func TestMuxServer(t *testing.T) {
+	// The muxed servers must declare the same provider schema.
+	t.Setenv("NAUTOBOT_URL", "")
+	for name, env := range map[string]string{"unset": "", "set": "https://nautobot.example.com/"} {
+		t.Run(name, func(t *testing.T) {
+			if env != "" {
+				t.Setenv("NAUTOBOT_URL", env)
+			}
+			server, err := providerFactories["nautobot"]()
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			rsp, err := server.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			for _, d := range rsp.Diagnostics {
+				t.Errorf("unexpected diagnostic: %s: %s", d.Summary, d.Detail)
+			}
+			if rsp.Provider == nil {
+				t.Fatal("missing provider schema")
+			}
+			for _, a := range rsp.Provider.Block.Attributes {
+				if a.Name == "url" && a.Required == (env != "") {
+					t.Errorf("got required %t for url", a.Required)
+				}
+			}
+		})
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
-	// You can add code here to run prior to any test case execution, for example assertions
-	// about the appropriate environment variables being set are common to see in a pre-check
-	// function.
+	// The tests run against a fake Nautobot, but they still need Terraform.
+	// Skip them when it isn't installed rather than letting the testing
+	// framework try to download it.
+	if os.Getenv("TF_ACC_TERRAFORM_PATH") != "" || os.Getenv("TF_ACC_TERRAFORM_VERSION") != "" {
+		return
+	}
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform not found in PATH, set TF_ACC_TERRAFORM_PATH or TF_ACC_TERRAFORM_VERSION to run this test")
+	}
+}
+
+// testAccPreCheckTerraformVersion skips the tests needing a version of
+// Terraform older than minVersion.
+func testAccPreCheckTerraformVersion(t *testing.T, minVersion string) {
+	testAccPreCheck(t)
+
+	v := os.Getenv("TF_ACC_TERRAFORM_VERSION")
+	if v == "" {
+		path := os.Getenv("TF_ACC_TERRAFORM_PATH")
+		if path == "" {
+			path, _ = exec.LookPath("terraform")
+		}
+		out, err := exec.Command(path, "version", "-json").Output()
+		if err != nil {
+			t.Fatalf("failed to get the version of Terraform: %s", err)
+		}
+		v = gjson.GetBytes(out, "terraform_version").String()
+	}
+
+	if version.Must(version.NewVersion(v)).LessThan(version.Must(version.NewVersion(minVersion))) {
+		t.Skipf("Terraform %s is older than %s", v, minVersion)
+	}
+}
+
+func TestProviderHeadersAndProxy(t *testing.T) {
+	var requests []string
+	// The proxy answers in place of Nautobot.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		if got := r.Header.Get("CF-Access-Client-Id"); got != "client" {
+			t.Errorf("%s: got CF-Access-Client-Id %q", r.URL, got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Token aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" {
+			t.Errorf("%s: got Authorization %q", r.URL, got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"nautobot-version":"1.5.8","data":{"virtual_machines":[]}}`))
+	}))
+	defer proxy.Close()
+
+	p := New("dev")()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"url":       "http://nautobot.invalid/api/",
+		"token":     "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"proxy_url": proxy.URL,
+		"headers": map[string]interface{}{
+			"CF-Access-Client-Id": "client",
+			// The provider's own headers take precedence.
+			"Authorization": "Bearer proxy",
+		},
+	}))
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if _, diags := readGraphQL(t, p.Meta(), map[string]tftypes.Value{
+		"query": tftypes.NewValue(tftypes.String, "query { virtual_machines { id } }"),
+	}); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	want := []string{
+		"http://nautobot.invalid/api/status/",
+		"http://nautobot.invalid/api/users/tokens/",
+		"http://nautobot.invalid/api/graphql/",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %v, want %v", requests, want)
+	}
 }
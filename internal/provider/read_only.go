@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// hasMutation returns whether the GraphQL document query defines a mutation,
+// whichever operation is selected to run. Documents which can't be split in
+// tokens are considered to, as it can't be told.
+func hasMutation(query string) bool {
+	tokens, err := graphQLTokens(query)
+	if err != nil {
+		return true
+	}
+	// Operations are defined at the top level, outside of selection sets and
+	// of variable definitions.
+	depth := 0
+	for _, t := range tokens {
+		switch t.value {
+		case "{", "(", "[":
+			depth++
+		case "}", ")", "]":
+			depth--
+		case "mutation":
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readOnlyError returns the diagnostics of Create, Update and Delete functions
+// called while the provider is in read-only mode, or nil when changes are
+// allowed. It must be called before sending any request.
+func readOnlyError(meta interface{}, action string) diag.Diagnostics {
+	if !meta.(*apiClient).ReadOnly {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Provider is read-only",
+		Detail:   fmt.Sprintf("Refusing to %s because the provider is configured with read_only = true.", action),
+	}}
+}
+
+// readOnlyTransport refuses to send requests that could change Nautobot, as
+// a safety net for the read-only mode. POSTs to the GraphQL endpoint are
+// allowed, as the GraphQL data source refuses mutations on its own.
+type readOnlyTransport struct {
+	next       http.RoundTripper
+	graphqlURL string
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.next.RoundTrip(req)
+	case http.MethodPost:
+		if req.URL.String() == t.graphqlURL {
+			return t.next.RoundTrip(req)
+		}
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, fmt.Errorf("refusing to send %s %s because the provider is read-only", req.Method, req.URL)
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	nb "github.com/nautobot/go-nautobot/pkg/nautobot"
+)
+
+func TestReadOnly(t *testing.T) {
+	var writes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/status/":
+			w.Write([]byte(`{"nautobot-version":"1.5.8"}`))
+		case r.URL.Path == "/api/graphql/":
+			w.Write([]byte(`{"data":{"manufacturers":[]}}`))
+		case r.Method != http.MethodGet:
+			writes = append(writes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.Write([]byte(`{"count":0,"results":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := New("dev")()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"url":       srv.URL,
+		"token":     testToken,
+		"read_only": true,
+	}))
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	meta := p.Meta()
+
+	d := resourceManufacturer().TestResourceData()
+	d.SetId("8a1b3f2e-5b5a-4d6e-9f3a-2b1c0d9e8f7a")
+	d.Set("name", "Juniper")
+
+	for name, fn := range map[string]func() bool{
+		"create": func() bool { return resourceManufacturerCreate(context.Background(), d, meta).HasError() },
+		"update": func() bool { return resourceManufacturerUpdate(context.Background(), d, meta).HasError() },
+		"delete": func() bool { return resourceManufacturerDelete(context.Background(), d, meta).HasError() },
+		"read":   func() bool { return !resourceManufacturerRead(context.Background(), d, meta).HasError() },
+	} {
+		if !fn() {
+			t.Errorf("%s: unexpected result", name)
+		}
+	}
+
+	// The transport refuses writes that would bypass the checks above.
+	c := meta.(*apiClient).Client
+	if _, err := c.DcimManufacturersCreateWithResponse(context.Background(), nb.DcimManufacturersCreateJSONRequestBody{Name: "Juniper"}); err == nil {
+		t.Error("expected the transport to refuse a POST")
+	}
+
+	if _, diags := readGraphQL(t, meta, map[string]tftypes.Value{
+		"query": tftypes.NewValue(tftypes.String, "query { manufacturers { id } }"),
+	}); diags.HasError() {
+		t.Errorf("unexpected error for a GraphQL query: %v", diags)
+	}
+	for _, query := range []string{
+		"# Create one\nmutation { createManufacturer { id } }",
+		", mutation { createManufacturer { id } }",
+		"fragment F on Manufacturer { id }\nmutation { createManufacturer { ...F } }",
+	} {
+		if _, diags := readGraphQL(t, meta, map[string]tftypes.Value{
+			"query": tftypes.NewValue(tftypes.String, query),
+		}); !diags.HasError() {
+			t.Errorf("%q: expected an error for a GraphQL mutation", query)
+		}
+	}
+	if _, diags := readGraphQL(t, meta, map[string]tftypes.Value{
+		"query":          tftypes.NewValue(tftypes.String, "query A { manufacturers { id } }\nmutation B { createManufacturer { id } }"),
+		"operation_name": tftypes.NewValue(tftypes.String, "B"),
+	}); !diags.HasError() {
+		t.Error("expected an error for a GraphQL mutation after a query")
+	}
+
+	if len(writes) > 0 {
+		t.Errorf("got write requests in read-only mode: %v", writes)
+	}
+}
+
+func TestHasMutation(t *testing.T) {
+	for query, want := range map[string]bool{
+		"query { manufacturers { id } }":                                       false,
+		"{ manufacturers(name: \"mutation\") { id } }":                         false,
+		"query Q($mutation: String) { manufacturers(name: $mutation) { id } }": false,
+		"# mutation\n{ manufacturers { id } }":                                 false,
+		"mutation { createManufacturer { id } }":                               true,
+		",mutation { createManufacturer { id } }":                              true,
+		"query A { manufacturers { id } } mutation B { deleteTag { id } }":     true,
+		"fragment F on Tag { id } mutation { createTag { ...F } }":             true,
+		"{ manufacturers(name: \"unterminated) { id } }":                       true,
+	} {
+		if got := hasMutation(query); got != want {
+			t.Errorf("%q: got %v, want %v", query, got, want)
+		}
+	}
+}
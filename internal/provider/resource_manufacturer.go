@@ -3,6 +3,8 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/deepmap/oapi-codegen/pkg/types"
@@ -24,6 +26,14 @@ func resourceManufacturer() *schema.Resource {
 		UpdateContext: resourceManufacturerUpdate,
 		DeleteContext: resourceManufacturerDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceManufacturerImport,
+		},
+
+		CustomizeDiff: resourceManufacturerCustomizeDiff,
+
+		Timeouts: defaultResourceTimeouts(),
+
 		Schema: map[string]*schema.Schema{
 			"created": {
 				Description: "Manufacturer's creation date.",
@@ -36,9 +46,10 @@ func resourceManufacturer() *schema.Resource {
 				Optional:    true,
 			},
 			"custom_fields": {
-				Description: "Manufacturer custom fields.",
+				Description: customFieldsDescription,
 				Type:        schema.TypeMap,
 				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 			"devicetype_count": {
 				Description: "Manufacturer's device count.",
@@ -77,17 +88,19 @@ func resourceManufacturer() *schema.Resource {
 				Optional:    true,
 				Computed:    true,
 			},
+			"on_conflict": onConflictSchema("manufacturer"),
 			"platform_count": {
 				Description: "Manufacturer's platform count.",
 				Type:        schema.TypeInt,
 				Computed:    true,
 			},
 			"slug": {
-				Description: "Manufacturer's slug.",
+				Description: "Manufacturer's slug. Only supported by Nautobot 1.x, where it defaults to the name in lower case with dashes instead of spaces.",
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
 			},
+			"tags": tagsSchema(),
 			"url": {
 				Description: "Manufacturer's URL.",
 				Type:        schema.TypeString,
@@ -98,7 +111,25 @@ func resourceManufacturer() *schema.Resource {
 	}
 }
 
+// resourceManufacturerCustomizeDiff refuses slugs with Nautobot 2.x, which
+// removed them.
+func resourceManufacturerCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if meta.(*apiClient).MajorVersion() < 2 {
+		return nil
+	}
+	// Only the configuration is checked: the state of manufacturers created
+	// with Nautobot 1.x keeps their slug until it is refreshed.
+	if config := d.GetRawConfig(); !config.IsNull() && !config.GetAttr("slug").IsNull() {
+		return fmt.Errorf("slug can't be set with Nautobot %d.x, manufacturers have no slug since Nautobot 2.0", meta.(*apiClient).MajorVersion())
+	}
+	return nil
+}
+
 func resourceManufacturerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := readOnlyError(meta, fmt.Sprintf("create manufacturer %s", d.Get("name").(string))); diags != nil {
+		return diags
+	}
+
 	c := meta.(*apiClient).Client
 	s := meta.(*apiClient).Server
 
@@ -110,46 +141,74 @@ func resourceManufacturerCreate(ctx context.Context, d *schema.ResourceData, met
 		m.Name = n
 	}
 
-	m.Description = &n
 	description, ok := d.GetOk("description")
 	if ok {
 		t := description.(string)
 		m.Description = &t
 	}
 
-	sl := strings.ReplaceAll(strings.ToLower(n), " ", "-")
-	m.Slug = &sl
+	// Manufacturers have no slug since Nautobot 2.0.
 	slug, ok := d.GetOk("slug")
-	if ok {
-		t := slug.(string)
-		m.Slug = &t
+	if meta.(*apiClient).MajorVersion() < 2 {
+		sl := strings.ReplaceAll(strings.ToLower(n), " ", "-")
+		m.Slug = &sl
+		if ok {
+			t := slug.(string)
+			m.Slug = &t
+		}
 	}
 
-	rsp, err := c.DcimManufacturersCreateWithResponse(
-		ctx,
-		nb.DcimManufacturersCreateJSONRequestBody(m))
+	if cf := d.Get("custom_fields").(map[string]interface{}); len(cf) > 0 {
+		data, diags := resourceManufacturerExpandCustomFields(ctx, meta, cf)
+		if diags != nil {
+			return diags
+		}
+		m.CustomFields = &nb.ManufacturerRequest_CustomFields{AdditionalProperties: data}
+	}
+
+	var tags []string
+	if v, ok := d.GetOk("tags"); ok {
+		var diags diag.Diagnostics
+		if tags, diags = expandTags(ctx, meta, v.(*schema.Set)); diags != nil {
+			return diags
+		}
+	}
+	body, err := withTags(nb.DcimManufacturersCreateJSONRequestBody(m), tags)
 	if err != nil {
 		return diag.Errorf("failed to create manufacturer %s on %s: %s", name.(string), s, err.Error())
 	}
-	data := string(rsp.Body)
 
-	dataName := gjson.Get(data, "name.0")
-
-	if dataName.String() == "manufacturer with this name already exists." {
-		rsp, err := c.DcimManufacturersListWithResponse(
-			ctx,
-			&nb.DcimManufacturersListParams{
-				NameIe: &[]string{n},
-			})
-
-		if err != nil {
-			return diag.Errorf("failed to get manufacturer %s from %s: %s", n, s, err.Error())
+	rsp, err := c.DcimManufacturersCreateWithBodyWithResponse(ctx, "application/json", body)
+	if err != nil {
+		return diag.Errorf("failed to create manufacturer %s on %s: %s", name.(string), s, err.Error())
+	}
+	// Nautobot refuses duplicate names and slugs, the existing manufacturer
+	// is then handled according to on_conflict.
+	if fields := conflictingFields(rsp.StatusCode(), rsp.Body, "name", "slug"); len(fields) > 0 {
+		existing, diags := resourceManufacturerFindConflict(ctx, meta, fields, n, m.Slug)
+		if diags != nil {
+			return diags
 		}
-		id := gjson.Get(string(rsp.Body), "results.0.id")
-
-		d.SetId(id.String())
-
-		return resourceManufacturerRead(ctx, d, meta)
+		if existing != nil {
+			want := map[string]interface{}{
+				"name":        n,
+				"description": d.Get("description").(string),
+			}
+			if m.Slug != nil {
+				want["slug"] = *m.Slug
+			}
+			diags := resolveConflict(ctx, d, meta, fmt.Sprintf("create manufacturer %s", n), "manufacturer", existing, want)
+			if diags.HasError() {
+				return diags
+			}
+
+			// The adopted manufacturer gets the attributes of the
+			// configuration, which may differ from its own with adopt.
+			return append(diags, resourceManufacturerUpdate(ctx, d, meta)...)
+		}
+	}
+	if diags := checkResponse(rsp.HTTPResponse, rsp.Body, fmt.Sprintf("create manufacturer %s", n), resourceManufacturer().Schema); diags != nil {
+		return diags
 	}
 
 	tflog.Trace(ctx, "manufacturer created", map[string]interface{}{
@@ -157,11 +216,53 @@ func resourceManufacturerCreate(ctx context.Context, d *schema.ResourceData, met
 		"data": []interface{}{description, slug},
 	})
 
-	id := gjson.Get(data, "id")
+	id := gjson.GetBytes(rsp.Body, "id")
 
 	d.SetId(id.String())
 
-	return resourceManufacturerRead(ctx, d, meta)
+	var diags diag.Diagnostics
+	if tags != nil && !gjson.GetBytes(rsp.Body, "tags").Exists() {
+		diags = tagsUnsupported("manufacturer")
+	}
+
+	return append(diags, resourceManufacturerRead(ctx, d, meta)...)
+}
+
+// resourceManufacturerFindConflict returns the manufacturer using the name or
+// slug that Nautobot refused in fields, or nil if there is none.
+func resourceManufacturerFindConflict(ctx context.Context, meta interface{}, fields []string, name string, slug *string) (map[string]interface{}, diag.Diagnostics) {
+	c := meta.(*apiClient).Client
+	s := meta.(*apiClient).Server
+
+	for _, field := range fields {
+		var params nb.DcimManufacturersListParams
+		switch {
+		case field == "name":
+			params.NameIe = &[]string{name}
+		case field == "slug" && slug != nil:
+			params.Slug = &[]string{*slug}
+		default:
+			continue
+		}
+
+		rsp, err := c.DcimManufacturersListWithResponse(ctx, &params)
+		if err != nil {
+			return nil, diag.Errorf("failed to get manufacturer %s from %s: %s", name, s, err.Error())
+		}
+		if diags := checkResponse(rsp.HTTPResponse, rsp.Body, fmt.Sprintf("get manufacturer %s", name), nil); diags != nil {
+			return nil, diags
+		}
+
+		if result := gjson.GetBytes(rsp.Body, "results.0"); result.Exists() {
+			item, ok := result.Value().(map[string]interface{})
+			if !ok {
+				return nil, diag.Errorf("failed to decode manufacturer %s from %s", name, s)
+			}
+			return item, nil
+		}
+	}
+
+	return nil, nil
 }
 
 func resourceManufacturerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -170,7 +271,7 @@ func resourceManufacturerRead(ctx context.Context, d *schema.ResourceData, meta
 	rsp, err := c.DcimManufacturersListWithResponse(
 		ctx,
 		&nb.DcimManufacturersListParams{
-			IdIe: &[]types.UUID{uuid.MustParse(id)},
+			Id: &[]types.UUID{uuid.MustParse(id)},
 		})
 
 	var diags diag.Diagnostics
@@ -179,6 +280,9 @@ func resourceManufacturerRead(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.Errorf("failed to get manufacturer %s from %s: %s", name, s, err.Error())
 	}
+	if diags := checkResponse(rsp.HTTPResponse, rsp.Body, fmt.Sprintf("get manufacturer %s", name), nil); diags != nil {
+		return diags
+	}
 
 	// If the Manufacturer is in the state file, but it is not in the Nautobot platform
 	// the response we get from DcimManufacturersListWithResponse is: {"count":0,"next":null,"previous":null,"results":[]}
@@ -201,42 +305,37 @@ func resourceManufacturerRead(ctx context.Context, d *schema.ResourceData, meta
 		return diag.Errorf("failed to decode manufacturer %s from %s: %s", name, s, err.Error())
 	}
 
-	d.Set("name", item["name"].(string))
-	d.Set("created", item["created"].(string))
-	d.Set("description", item["description"].(string))
-	d.Set("display", item["display"].(string))
-	d.Set("id", item["id"].(string))
-	d.Set("notes_url", item["notes_url"].(string))
-	d.Set("slug", item["slug"].(string))
-	d.Set("url", item["url"].(string))
-	d.Set("last_updated", item["last_updated"].(string))
-
-	switch v := item["devicetype_count"].(type) {
-	case int:
-		d.Set("devicetype_count", v)
-	case float64:
-		d.Set("devicetype_count", int(v))
-	default:
+	for k, v := range flattenManufacturer(item) {
+		d.Set(k, v)
 	}
-	switch v := item["inventoryitem_count"].(type) {
-	case int:
-		d.Set("inventoryitem_count", v)
-	case float64:
-		d.Set("inventoryitem_count", int(v))
-	default:
+
+	// Objects which Nautobot doesn't tag have no tags field.
+	if v, ok := item["tags"]; ok {
+		tags, diags := flattenTags(ctx, meta, d.Get("tags").(*schema.Set), v)
+		if diags != nil {
+			return diags
+		}
+		d.Set("tags", tags)
 	}
-	switch v := item["platform_count"].(type) {
-	case int:
-		d.Set("platform_count", v)
-	case float64:
-		d.Set("platform_count", int(v))
-	default:
+
+	// Only the custom fields of the configuration are managed.
+	if cf := d.Get("custom_fields").(map[string]interface{}); len(cf) > 0 {
+		types, diags := meta.(*apiClient).customFields(ctx, manufacturerContentType)
+		if diags != nil {
+			return diags
+		}
+		data, _ := item["custom_fields"].(map[string]interface{})
+		d.Set("custom_fields", flattenCustomFields(types, cf, data))
 	}
 
 	return diags
 }
 
 func resourceManufacturerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := readOnlyError(meta, fmt.Sprintf("update manufacturer %s", d.Get("name").(string))); diags != nil {
+		return diags
+	}
+
 	c := meta.(*apiClient).Client
 	s := meta.(*apiClient).Server
 
@@ -256,26 +355,66 @@ func resourceManufacturerUpdate(ctx context.Context, d *schema.ResourceData, met
 
 	slug := d.Get("slug").(string)
 	if d.HasChange("slug") {
-		m.Description = &slug
+		m.Slug = &slug
+	}
+
+	if d.HasChange("custom_fields") {
+		o, n := d.GetChange("custom_fields")
+		data, diags := resourceManufacturerExpandCustomFields(ctx, meta, n.(map[string]interface{}))
+		if diags != nil {
+			return diags
+		}
+		// Custom fields removed from the configuration are cleared.
+		for k := range o.(map[string]interface{}) {
+			if _, ok := data[k]; !ok {
+				data[k] = nil
+			}
+		}
+		m.CustomFields = &nb.PatchedManufacturerRequest_CustomFields{AdditionalProperties: data}
 	}
 
-	_, err := c.DcimManufacturersPartialUpdateWithResponse(
+	var tags []string
+	if d.HasChange("tags") {
+		var diags diag.Diagnostics
+		if tags, diags = expandTags(ctx, meta, d.Get("tags").(*schema.Set)); diags != nil {
+			return diags
+		}
+	}
+	body, err := withTags(nb.DcimManufacturersPartialUpdateJSONRequestBody(m), tags)
+	if err != nil {
+		return diag.Errorf("failed to update manufacturer %s on %s: %s", name, s, err.Error())
+	}
+
+	rsp, err := c.DcimManufacturersPartialUpdateWithBodyWithResponse(
 		ctx,
 		uuid.MustParse(id),
-		nb.DcimManufacturersPartialUpdateJSONRequestBody(m))
+		"application/json",
+		body)
 	if err != nil {
 		return diag.Errorf("failed to update manufacturer %s on %s: %s", name, s, err.Error())
 	}
+	if diags := checkResponse(rsp.HTTPResponse, rsp.Body, fmt.Sprintf("update manufacturer %s", name), resourceManufacturer().Schema); diags != nil {
+		return diags
+	}
 
 	tflog.Trace(ctx, "manufacturer updated", map[string]interface{}{
 		"name": name,
 		"data": []string{desc, slug},
 	})
 
-	return resourceManufacturerRead(ctx, d, meta)
+	var diags diag.Diagnostics
+	if tags != nil && !gjson.GetBytes(rsp.Body, "tags").Exists() {
+		diags = tagsUnsupported("manufacturer")
+	}
+
+	return append(diags, resourceManufacturerRead(ctx, d, meta)...)
 }
 
 func resourceManufacturerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if diags := readOnlyError(meta, fmt.Sprintf("delete manufacturer %s", d.Get("name").(string))); diags != nil {
+		return diags
+	}
+
 	var diags diag.Diagnostics
 
 	c := meta.(*apiClient).Client
@@ -284,12 +423,18 @@ func resourceManufacturerDelete(ctx context.Context, d *schema.ResourceData, met
 	id := d.Get("id").(string)
 	name := d.Get("name").(string)
 
-	_, err := c.DcimManufacturersDestroy(
+	rsp, err := c.DcimManufacturersDestroyWithResponse(
 		ctx,
 		uuid.MustParse(id))
 	if err != nil {
 		return diag.Errorf("failed to delete manufacturer %s on %s: %s", name, s, err.Error())
 	}
+	// The manufacturer is already gone.
+	if rsp.StatusCode() != http.StatusNotFound {
+		if diags := checkResponse(rsp.HTTPResponse, rsp.Body, fmt.Sprintf("delete manufacturer %s", name), nil); diags != nil {
+			return diags
+		}
+	}
 
 	// d.SetId("") is automatically called assuming delete returns no errors, but
 	// it is added here for explicitness.
@@ -297,3 +442,119 @@ func resourceManufacturerDelete(ctx context.Context, d *schema.ResourceData, met
 
 	return diags
 }
+
+// resourceManufacturerImport imports a manufacturer by UUID, or looks it up
+// by name or slug with an ID like "name=Juniper" or "slug=juniper".
+func resourceManufacturerImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	id := d.Id()
+	if _, err := uuid.Parse(id); err == nil {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	field, value, _ := strings.Cut(id, "=")
+	if (field != "name" && field != "slug") || value == "" {
+		return nil, fmt.Errorf("invalid manufacturer ID %q, it must be a UUID, name=<name> or slug=<slug>", id)
+	}
+
+	item, err := findManufacturer(ctx, meta, field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(item["id"].(string))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// findManufacturer returns the manufacturer whose field, "id", "name" or
+// "slug", is value. It fails unless exactly one manufacturer matches.
+func findManufacturer(ctx context.Context, meta interface{}, field string, value string) (map[string]interface{}, error) {
+	c := meta.(*apiClient).Client
+	s := meta.(*apiClient).Server
+	lookup := fmt.Sprintf("%s=%s", field, value)
+
+	var params nb.DcimManufacturersListParams
+	switch field {
+	case "id":
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manufacturer UUID %q", value)
+		}
+		params.Id = &[]types.UUID{id}
+	case "name":
+		params.Name = &[]string{value}
+	case "slug":
+		if meta.(*apiClient).MajorVersion() >= 2 {
+			return nil, fmt.Errorf("can't look up manufacturer %s, manufacturers have no slug since Nautobot 2.0", lookup)
+		}
+		params.Slug = &[]string{value}
+	}
+
+	rsp, err := c.DcimManufacturersListWithResponse(ctx, &params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manufacturer %s from %s: %w", lookup, s, err)
+	}
+	if diags := checkResponse(rsp.HTTPResponse, rsp.Body, fmt.Sprintf("get manufacturer %s", lookup), nil); diags != nil {
+		return nil, diagsError(diags)
+	}
+
+	switch count := gjson.GetBytes(rsp.Body, "count").Int(); count {
+	case 1:
+	case 0:
+		return nil, fmt.Errorf("no manufacturer matches %s on %s", lookup, s)
+	default:
+		return nil, fmt.Errorf("%d manufacturers match %s on %s, use the UUID of the one you want", count, lookup, s)
+	}
+
+	item, ok := gjson.GetBytes(rsp.Body, "results.0").Value().(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to decode manufacturer %s from %s", lookup, s)
+	}
+	return item, nil
+}
+
+// manufacturerContentType is the content type of manufacturers, to which
+// custom fields are attached.
+const manufacturerContentType = "dcim.manufacturer"
+
+// resourceManufacturerExpandCustomFields converts the custom_fields of a
+// manufacturer to the values sent to Nautobot.
+func resourceManufacturerExpandCustomFields(ctx context.Context, meta interface{}, values map[string]interface{}) (map[string]interface{}, diag.Diagnostics) {
+	if len(values) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	types, diags := meta.(*apiClient).customFields(ctx, manufacturerContentType)
+	if diags != nil {
+		return nil, diags
+	}
+	return expandCustomFields(types, values)
+}
+
+// flattenManufacturer returns the attributes of the manufacturer item
+// returned by Nautobot. The counts renamed by Nautobot 2.x get their 1.x
+// names, and the slug it removed is empty.
+func flattenManufacturer(item map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+
+	for _, k := range []string{"name", "created", "description", "display", "id", "notes_url", "slug", "url", "last_updated"} {
+		v, _ := item[k].(string)
+		m[k] = v
+	}
+
+	for k, names := range map[string][]string{
+		"devicetype_count":    {"devicetype_count", "device_type_count"},
+		"inventoryitem_count": {"inventoryitem_count", "inventory_item_count"},
+		"platform_count":      {"platform_count"},
+	} {
+		for _, name := range names {
+			switch v := item[name].(type) {
+			case int:
+				m[k] = v
+			case float64:
+				m[k] = int(v)
+			}
+		}
+	}
+
+	return m
+}
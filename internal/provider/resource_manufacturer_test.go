@@ -1,38 +1,543 @@
 package provider
 
 import (
+	"context"
+	"net/http"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestAccResourceManufacturer(t *testing.T) {
-	t.Skip("resource not yet implemented, remove this once you add your own code")
+	f := newFakeNautobot(t)
 
 	resource.UnitTest(t, resource.TestCase{
-		PreCheck:          func() { testAccPreCheck(t) },
-		ProviderFactories: providerFactories,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV5ProviderFactories: providerFactories,
+		CheckDestroy: func(*terraform.State) error {
+			if got := f.get("dcim/manufacturers"); len(got) > 0 {
+				t.Errorf("manufacturers left after destroy: %v", got)
+			}
+			return nil
+		},
 		Steps: []resource.TestStep{
 			{
-				Config: testAccResourceManufacturer,
+				Config: f.providerConfig() + testAccResourceManufacturer,
 				Check: resource.ComposeTestCheckFunc(
 					resource.TestMatchResourceAttr(
 						"nautobot_manufacturer.test", "slug", regexp.MustCompile("^juniper$")),
+					resource.TestMatchResourceAttr(
+						"nautobot_manufacturer.test", "id", regexp.MustCompile("^[0-9a-f-]{36}$")),
+				),
+			},
+			{
+				Config: f.providerConfig() + testAccResourceManufacturerUpdated,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("nautobot_manufacturer.test", "slug", "juniper-networks"),
+					resource.TestCheckResourceAttr("nautobot_manufacturer.test", "description", "Routers"),
 				),
 			},
+			{
+				ResourceName:      "nautobot_manufacturer.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "nautobot_manufacturer.test",
+				ImportState:       true,
+				ImportStateId:     "name=Juniper",
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "nautobot_manufacturer.test",
+				ImportState:       true,
+				ImportStateId:     "slug=juniper-networks",
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:  "nautobot_manufacturer.test",
+				ImportState:   true,
+				ImportStateId: "name=Cisco",
+				ExpectError:   regexp.MustCompile("no manufacturer matches name=Cisco"),
+			},
 		},
 	})
+}
 
+func TestAccResourceManufacturerImportBlock(t *testing.T) {
+	f := newFakeNautobot(t)
+	juniper := f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper", "description": "Routers"})
+
+	resource.UnitTest(t, resource.TestCase{
+		// import blocks were added in Terraform 1.5.
+		PreCheck:                 func() { testAccPreCheckTerraformVersion(t, "1.5.0") },
+		ProtoV5ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: f.providerConfig() + testAccResourceManufacturerImportBlock,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("nautobot_manufacturer.test", "id", juniper["id"].(string)),
+					resource.TestCheckResourceAttr("nautobot_manufacturer.test", "description", "Routers"),
+				),
+			},
+		},
+	})
+
+	if got := f.get("dcim/manufacturers"); len(got) != 1 {
+		t.Errorf("got %d manufacturers, want the imported one only", len(got))
+	}
 }
 
 const testAccResourceManufacturer = `
-provider "nautobot" {
-	url = "https://demo.nautobot.com/api/"
-	token = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
-  }
-
 resource "nautobot_manufacturer" "test" {
 	name = "Juniper"
 }
 `
+
+const testAccResourceManufacturerUpdated = `
+resource "nautobot_manufacturer" "test" {
+	name        = "Juniper"
+	slug        = "juniper-networks"
+	description = "Routers"
+}
+`
+
+const testAccResourceManufacturerImportBlock = `
+import {
+	to = nautobot_manufacturer.test
+	id = "name=Juniper"
+}
+
+resource "nautobot_manufacturer" "test" {
+	name        = "Juniper"
+	description = "Routers"
+}
+`
+
+func TestResourceManufacturerImport(t *testing.T) {
+	f := newFakeNautobot(t)
+	juniper := f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper"})
+	meta := f.meta(t, nil)
+
+	for _, tc := range []struct {
+		id      string
+		wantErr string
+	}{
+		{id: juniper["id"].(string)},
+		{id: "name=Juniper"},
+		{id: "slug=juniper"},
+		{id: "name=Cisco", wantErr: "no manufacturer matches name=Cisco"},
+		{id: "Juniper", wantErr: `invalid manufacturer ID "Juniper"`},
+		{id: "name=", wantErr: `invalid manufacturer ID "name="`},
+		{id: "description=Routers", wantErr: `invalid manufacturer ID "description=Routers"`},
+	} {
+		d := resourceManufacturer().TestResourceData()
+		d.SetId(tc.id)
+		got, err := resourceManufacturerImport(context.Background(), d, meta)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: got error %v, want %q", tc.id, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.id, err)
+			continue
+		}
+		if len(got) != 1 || got[0].Id() != juniper["id"] {
+			t.Errorf("%s: got ID %s", tc.id, got[0].Id())
+		}
+	}
+
+	// Names are unique, but lookups could still be ambiguous with a
+	// case-insensitive database collation.
+	f.fail(http.MethodGet, "/api/dcim/manufacturers/", http.StatusOK, `{"count":2,"next":null,"previous":null,"results":[{"id":"a"},{"id":"b"}]}`, 1)
+	d := resourceManufacturer().TestResourceData()
+	d.SetId("name=juniper")
+	if _, err := resourceManufacturerImport(context.Background(), d, meta); err == nil || !strings.Contains(err.Error(), "2 manufacturers match name=juniper") {
+		t.Errorf("got error %v", err)
+	}
+
+	// Nautobot 2.x has no slugs.
+	d = resourceManufacturer().TestResourceData()
+	d.SetId("slug=juniper")
+	if _, err := resourceManufacturerImport(context.Background(), d, newFakeNautobotVersion(t, "2.1.4").meta(t, nil)); err == nil {
+		t.Error("expected an error for a slug with Nautobot 2.x")
+	}
+}
+
+func TestResourceManufacturerCRUD(t *testing.T) {
+	f := newFakeNautobot(t)
+	meta := f.meta(t, nil)
+	ctx := context.Background()
+
+	d := resourceManufacturer().TestResourceData()
+	d.Set("name", "Juniper Networks")
+	if diags := resourceManufacturerCreate(ctx, d, meta); diags.HasError() {
+		t.Fatalf("create: unexpected error %v", diags)
+	}
+	if got := d.Get("slug"); got != "juniper-networks" {
+		t.Errorf("create: got slug %v", got)
+	}
+	if got := f.get("dcim/manufacturers"); len(got) != 1 || got[0]["id"] != d.Id() {
+		t.Fatalf("create: got %v", got)
+	}
+	// Manufacturers created without description don't get their name as one.
+	if got := f.get("dcim/manufacturers")[0]["description"]; got != "" {
+		t.Errorf("create: got description %v, want none", got)
+	}
+
+	// The diff from an empty state changes every attribute.
+	id := d.Id()
+	d = schema.TestResourceDataRaw(t, resourceManufacturer().Schema, map[string]interface{}{
+		"name":        "Juniper Networks",
+		"description": "Routers",
+		"slug":        "juniper",
+	})
+	d.SetId(id)
+	if diags := resourceManufacturerUpdate(ctx, d, meta); diags.HasError() {
+		t.Fatalf("update: unexpected error %v", diags)
+	}
+	if got := f.get("dcim/manufacturers")[0]; got["description"] != "Routers" || got["slug"] != "juniper" {
+		t.Errorf("update: got %v", got)
+	}
+
+	// Resources deleted outside of Terraform are removed from the state.
+	f.fail(http.MethodGet, "/api/dcim/manufacturers/", http.StatusOK, `{"count":0,"next":null,"previous":null,"results":[]}`, 1)
+	if diags := resourceManufacturerRead(ctx, d, meta); diags.HasError() {
+		t.Fatalf("read: unexpected error %v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("read: got ID %q for a deleted manufacturer", d.Id())
+	}
+	d.SetId(f.get("dcim/manufacturers")[0]["id"].(string))
+
+	f.fail(http.MethodDelete, "/api/dcim/manufacturers/", http.StatusConflict, `{"detail":"Cannot delete manufacturer Juniper Networks, it is used by 3 device types."}`, 1)
+	if diags := resourceManufacturerDelete(ctx, d, meta); !diags.HasError() {
+		t.Error("delete: expected an error")
+	}
+	if diags := resourceManufacturerDelete(ctx, d, meta); diags.HasError() {
+		t.Fatalf("delete: unexpected error %v", diags)
+	}
+	if got := f.get("dcim/manufacturers"); len(got) != 0 {
+		t.Errorf("delete: got %v", got)
+	}
+}
+
+func TestResourceManufacturerNautobot2(t *testing.T) {
+	f := newFakeNautobotVersion(t, "2.1.4")
+	meta := f.meta(t, nil)
+	ctx := context.Background()
+
+	if got := meta.(*apiClient).APIVersion; got != "2.0" {
+		t.Fatalf("got API version %s", got)
+	}
+
+	d := resourceManufacturer().TestResourceData()
+	d.Set("name", "Juniper Networks")
+	if diags := resourceManufacturerCreate(ctx, d, meta); diags.HasError() {
+		t.Fatalf("create: unexpected error %v", diags)
+	}
+	if got := f.get("dcim/manufacturers"); len(got) != 1 || got[0]["slug"] != nil {
+		t.Errorf("create: got %v", got)
+	}
+	if got := d.Get("slug"); got != "" {
+		t.Errorf("read: got slug %v", got)
+	}
+	if got := d.Get("devicetype_count"); got != 0 {
+		t.Errorf("read: got devicetype_count %v", got)
+	}
+
+	// plan diffs config against the attributes of the state, along with the
+	// raw configuration which Terraform sends to plan.
+	plan := func(meta interface{}, state map[string]string, config map[string]interface{}) error {
+		t.Helper()
+		raw := map[string]cty.Value{}
+		for k, ty := range resourceManufacturer().CoreConfigSchema().ImpliedType().AttributeTypes() {
+			raw[k] = cty.NullVal(ty)
+			if v, ok := config[k].(string); ok {
+				raw[k] = cty.StringVal(v)
+			}
+		}
+		s := &terraform.InstanceState{ID: state["id"], Attributes: state, RawConfig: cty.ObjectVal(raw)}
+		_, err := resourceManufacturer().Diff(ctx, s, terraform.NewResourceConfigRaw(config), meta)
+		return err
+	}
+	cisco := map[string]interface{}{"name": "Cisco", "slug": "cisco"}
+	if err := plan(meta, nil, cisco); err == nil {
+		t.Error("expected an error for a slug with Nautobot 2.x")
+	}
+	if err := plan(newFakeNautobot(t).meta(t, nil), nil, cisco); err != nil {
+		t.Errorf("unexpected error for a slug with Nautobot 1.x: %s", err)
+	}
+	// Without refresh, the state still holds the slug from Nautobot 1.x.
+	state := map[string]string{"id": d.Id(), "name": "Juniper Networks", "slug": "juniper-networks"}
+	if err := plan(meta, state, map[string]interface{}{"name": "Juniper Networks"}); err != nil {
+		t.Errorf("unexpected error for a slug only in the state: %s", err)
+	}
+}
+
+func TestResourceManufacturerConflict(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		provider string
+		resource string
+		config   map[string]interface{}
+		adopted  bool
+		// description is the one of the manufacturer once adopted.
+		description string
+		path        string
+	}{
+		{
+			name:   "error by default",
+			config: map[string]interface{}{"name": "Juniper Networks", "description": "Routers"},
+			path:   "name",
+		},
+		{
+			name:        "adopt from the provider",
+			provider:    "adopt",
+			config:      map[string]interface{}{"name": "Juniper Networks"},
+			adopted:     true,
+			description: "Routers",
+		},
+		{
+			name:        "adopt updates to the configuration",
+			provider:    "adopt",
+			config:      map[string]interface{}{"name": "Juniper Networks", "description": "Switches"},
+			adopted:     true,
+			description: "Switches",
+		},
+		{
+			name:     "resource overrides the provider",
+			provider: "adopt",
+			resource: "error",
+			config:   map[string]interface{}{"name": "Juniper Networks"},
+			path:     "name",
+		},
+		{
+			name:        "adopt if matching",
+			resource:    "adopt_if_matching",
+			config:      map[string]interface{}{"name": "Juniper Networks", "description": "Routers"},
+			adopted:     true,
+			description: "Routers",
+		},
+		{
+			name:     "don't adopt if not matching",
+			resource: "adopt_if_matching",
+			config:   map[string]interface{}{"name": "Juniper Networks", "description": "Switches"},
+			path:     "on_conflict",
+		},
+		{
+			name:     "conflicting slug",
+			resource: "adopt_if_matching",
+			config:   map[string]interface{}{"name": "Juniper", "description": "Routers", "slug": "juniper-networks"},
+			path:     "on_conflict",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeNautobot(t)
+			id := f.add("dcim/manufacturers", map[string]interface{}{"name": "Juniper Networks", "description": "Routers"})["id"]
+			raw := map[string]interface{}{}
+			if tc.provider != "" {
+				raw["on_conflict"] = tc.provider
+			}
+			meta := f.meta(t, raw)
+
+			config := map[string]interface{}{}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+			if tc.resource != "" {
+				config["on_conflict"] = tc.resource
+			}
+			d := schema.TestResourceDataRaw(t, resourceManufacturer().Schema, config)
+			diags := resourceManufacturerCreate(context.Background(), d, meta)
+
+			if tc.adopted {
+				if diags.HasError() {
+					t.Fatalf("unexpected error %v", diags)
+				}
+				if len(diags) != 1 || diags[0].Severity != diag.Warning {
+					t.Errorf("expected a warning, got %v", diags)
+				}
+				if d.Id() != id {
+					t.Errorf("got ID %q, want %q", d.Id(), id)
+				}
+				if got := d.Get("description"); got != tc.description {
+					t.Errorf("got description %v, want %s", got, tc.description)
+				}
+				if got := f.get("dcim/manufacturers")[0]["description"]; got != tc.description {
+					t.Errorf("got description %v in Nautobot, want %s", got, tc.description)
+				}
+				return
+			}
+
+			if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath(tc.path)) {
+				t.Errorf("expected an error on %s, got %v", tc.path, diags)
+			}
+			if d.Id() != "" {
+				t.Errorf("got ID %q", d.Id())
+			}
+			if got := f.get("dcim/manufacturers"); len(got) != 1 {
+				t.Errorf("got %v", got)
+			}
+		})
+	}
+}
+
+func TestResourceManufacturerCustomFields(t *testing.T) {
+	for _, version := range []string{"1.5.8", "2.1.4"} {
+		t.Run(version, func(t *testing.T) {
+			f := newFakeNautobotVersion(t, version)
+			f.add("extras/custom-fields", map[string]interface{}{"label": "Rack units", "type": "integer", "content_types": []interface{}{"dcim.manufacturer"}})
+			f.add("extras/custom-fields", map[string]interface{}{"label": "EOL", "type": "boolean", "content_types": []interface{}{"dcim.manufacturer"}})
+			f.add("extras/custom-fields", map[string]interface{}{"label": "Regions", "type": "multi-select", "content_types": []interface{}{"dcim.manufacturer"}})
+			f.add("extras/custom-fields", map[string]interface{}{"label": "Contract", "type": "text", "content_types": []interface{}{"dcim.device"}})
+			meta := f.meta(t, nil)
+			ctx := context.Background()
+
+			d := schema.TestResourceDataRaw(t, resourceManufacturer().Schema, map[string]interface{}{
+				"name": "Juniper",
+				"custom_fields": map[string]interface{}{
+					"rack_units": "42",
+					"regions":    `["emea"]`,
+				},
+			})
+			if diags := resourceManufacturerCreate(ctx, d, meta); diags.HasError() {
+				t.Fatalf("create: unexpected error %v", diags)
+			}
+			want := map[string]interface{}{"rack_units": float64(42), "eol": nil, "regions": []interface{}{"emea"}}
+			if got := f.get("dcim/manufacturers")[0]["custom_fields"]; !reflect.DeepEqual(got, want) {
+				t.Errorf("create: got %v", got)
+			}
+
+			// Only the drift of the declared custom fields is reported.
+			f.get("dcim/manufacturers")[0]["custom_fields"].(map[string]interface{})["eol"] = true
+			if diags := resourceManufacturerRead(ctx, d, meta); diags.HasError() {
+				t.Fatalf("read: unexpected error %v", diags)
+			}
+			if got := d.Get("custom_fields"); !reflect.DeepEqual(got, map[string]interface{}{"rack_units": "42", "regions": `["emea"]`}) {
+				t.Errorf("read: got %v", got)
+			}
+			f.get("dcim/manufacturers")[0]["custom_fields"].(map[string]interface{})["rack_units"] = float64(48)
+			if diags := resourceManufacturerRead(ctx, d, meta); diags.HasError() {
+				t.Fatalf("read: unexpected error %v", diags)
+			}
+			if got := d.Get("custom_fields.rack_units"); got != "48" {
+				t.Errorf("read: got rack_units %v", got)
+			}
+
+			// Custom fields removed from the configuration are cleared.
+			update := func(customFields map[string]interface{}) *schema.ResourceData {
+				t.Helper()
+				state := d.State()
+				diff, err := resourceManufacturer().Diff(ctx, state, terraform.NewResourceConfigRaw(map[string]interface{}{
+					"name":          "Juniper",
+					"custom_fields": customFields,
+				}), meta)
+				if err != nil {
+					t.Fatal(err)
+				}
+				d, err := schema.InternalMap(resourceManufacturer().Schema).Data(state, diff)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return d
+			}
+			d = update(map[string]interface{}{"eol": "false"})
+			if diags := resourceManufacturerUpdate(ctx, d, meta); diags.HasError() {
+				t.Fatalf("update: unexpected error %v", diags)
+			}
+			want = map[string]interface{}{"rack_units": nil, "eol": false, "regions": nil}
+			if got := f.get("dcim/manufacturers")[0]["custom_fields"]; !reflect.DeepEqual(got, want) {
+				t.Errorf("update: got %v", got)
+			}
+
+			d = update(map[string]interface{}{"contract": "x"})
+			diags := resourceManufacturerUpdate(ctx, d, meta)
+			if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath("custom_fields").IndexString("contract")) {
+				t.Errorf("update: got %v", diags)
+			}
+
+			if got := strings.Count(strings.Join(f.requestLog(), "\n"), "GET /api/extras/custom-fields/"); got != 1 {
+				t.Errorf("got %d requests for the custom fields", got)
+			}
+		})
+	}
+}
+
+func TestResourceManufacturerTags(t *testing.T) {
+	for _, version := range []string{"1.5.8", "2.1.4"} {
+		t.Run(version, func(t *testing.T) {
+			f := newFakeNautobotVersion(t, version)
+			core := f.add("extras/tags", map[string]interface{}{"name": "Core Network"})
+			f.add("extras/tags", map[string]interface{}{"name": "Lab"})
+			meta := f.meta(t, nil)
+			ctx := context.Background()
+
+			ref := "Core Network"
+			if version < "2" {
+				ref = "core-network"
+			}
+			d := schema.TestResourceDataRaw(t, resourceManufacturer().Schema, map[string]interface{}{
+				"name": "Juniper",
+				"tags": []interface{}{ref},
+			})
+			if diags := resourceManufacturerCreate(ctx, d, meta); diags.HasError() {
+				t.Fatalf("create: unexpected error %v", diags)
+			}
+			if got := f.get("dcim/manufacturers")[0]["tags"].([]interface{}); len(got) != 1 || got[0].(map[string]interface{})["id"] != core["id"] {
+				t.Errorf("create: got tags %v", got)
+			}
+			if got := d.Get("tags").(*schema.Set).List(); len(got) != 1 || got[0] != ref {
+				t.Errorf("create: got tags %v", got)
+			}
+
+			// Tags created and set outside of Terraform are read by name.
+			lab := f.add("extras/tags", map[string]interface{}{"name": "Lab 2"})
+			obj := f.get("dcim/manufacturers")[0]
+			obj["tags"] = append(obj["tags"].([]interface{}), map[string]interface{}{"id": lab["id"]})
+			if diags := resourceManufacturerRead(ctx, d, meta); diags.HasError() {
+				t.Fatalf("read: unexpected error %v", diags)
+			}
+			if got := d.Get("tags").(*schema.Set); got.Len() != 2 || !got.Contains(ref) || !got.Contains("Lab 2") {
+				t.Errorf("read: got tags %v", got.List())
+			}
+
+			state := d.State()
+			diff, err := resourceManufacturer().Diff(ctx, state, terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name": "Juniper",
+				"tags": []interface{}{"Lab"},
+			}), meta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err = schema.InternalMap(resourceManufacturer().Schema).Data(state, diff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diags := resourceManufacturerUpdate(ctx, d, meta); diags.HasError() {
+				t.Fatalf("update: unexpected error %v", diags)
+			}
+			if got := d.Get("tags").(*schema.Set).List(); len(got) != 1 || got[0] != "Lab" {
+				t.Errorf("update: got tags %v", got)
+			}
+
+			d = schema.TestResourceDataRaw(t, resourceManufacturer().Schema, map[string]interface{}{
+				"name": "Cisco",
+				"tags": []interface{}{"Core"},
+			})
+			diags := resourceManufacturerCreate(ctx, d, meta)
+			if len(diags) != 1 || diags[0].Summary != "Unknown tag" {
+				t.Errorf("create: got %v", diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+type idempotentKey struct{}
+
+// withIdempotent marks the requests made with ctx as safe to replay, even if
+// their HTTP method is not. It is used for read-only POSTs such as GraphQL
+// queries.
+func withIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// retryTransport retries requests that failed for transient reasons: 429,
+// 502, 503 and 504 responses, dropped connections and timeouts. Waits between
+// attempts grow exponentially with jitter, unless the server sends a
+// Retry-After header, and never exceed waitMax.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+}
+
+func newRetryTransport(next http.RoundTripper, maxRetries int, waitMin, waitMax time.Duration) *retryTransport {
+	return &retryTransport{
+		next:       next,
+		maxRetries: maxRetries,
+		waitMin:    waitMin,
+		waitMax:    waitMax,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			// The previous attempt consumed the body, get a fresh copy.
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+
+		rsp, err := t.next.RoundTrip(r)
+		if attempt >= t.maxRetries || !shouldRetry(req, rsp, err) {
+			return rsp, err
+		}
+
+		wait := t.backoff(attempt, rsp)
+		fields := map[string]interface{}{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["status"] = rsp.StatusCode
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			rsp.Body.Close()
+		}
+		tflog.Debug(ctx, "retrying Nautobot API request", fields)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether req can be sent again after getting rsp or err.
+// Requests that are not idempotent are only retried when the server clearly
+// never processed them.
+func shouldRetry(req *http.Request, rsp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body can't be replayed.
+		return false
+	}
+
+	idempotent := isIdempotent(req)
+
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			// The connection was never established.
+			return true
+		}
+		if !idempotent {
+			return false
+		}
+		return errors.Is(err, errRequestTimeout) ||
+			errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF)
+	}
+
+	switch rsp.StatusCode {
+	case http.StatusTooManyRequests:
+		// The request was rejected before being processed.
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	v, _ := req.Context().Value(idempotentKey{}).(bool)
+	return v
+}
+
+// backoff returns how long to wait before the next attempt. A Retry-After
+// header sent by the server takes precedence over the exponential backoff,
+// but is capped to waitMax so that a proxy asking for hours doesn't stall
+// the run.
+func (t *retryTransport) backoff(attempt int, rsp *http.Response) time.Duration {
+	if rsp != nil {
+		if wait, ok := retryAfter(rsp.Header.Get("Retry-After")); ok {
+			if wait > t.waitMax {
+				return t.waitMax
+			}
+			return wait
+		}
+	}
+
+	wait := t.waitMax
+	if attempt < 32 {
+		if w := t.waitMin << uint(attempt); w > 0 && w < t.waitMax {
+			wait = w
+		}
+	}
+	if wait <= 0 {
+		return 0
+	}
+
+	// Use "equal jitter", so that we always wait at least half the backoff.
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1))
+}
+
+// retryAfter parses a Retry-After header, which is either a number of seconds
+// or an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		if s < 0 {
+			return 0, false
+		}
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
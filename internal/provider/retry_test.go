@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRetryClient(maxRetries int) *http.Client {
+	return &http.Client{
+		Transport: newRetryTransport(http.DefaultTransport, maxRetries, time.Millisecond, 5*time.Millisecond),
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		ctx      context.Context
+		statuses []int
+		want     int
+		attempts int32
+	}{
+		{"get retried on 502", http.MethodGet, context.Background(), []int{502, 503, 200}, 200, 3},
+		{"get gives up", http.MethodGet, context.Background(), []int{504, 504, 504, 504}, 504, 3},
+		{"get not retried on 500", http.MethodGet, context.Background(), []int{500, 200}, 500, 1},
+		{"post retried on 429", http.MethodPost, context.Background(), []int{429, 201}, 201, 2},
+		{"post not retried on 502", http.MethodPost, context.Background(), []int{502, 201}, 502, 1},
+		{"idempotent post retried on 502", http.MethodPost, withIdempotent(context.Background()), []int{502, 200}, 200, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				body, _ := io.ReadAll(r.Body)
+				if r.Method == http.MethodPost && string(body) != `{"name":"Juniper"}` {
+					t.Errorf("attempt %d: unexpected body %q", n, body)
+				}
+				w.WriteHeader(tc.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequestWithContext(tc.ctx, tc.method, srv.URL, strings.NewReader(`{"name":"Juniper"}`))
+			rsp, err := testRetryClient(2).Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			rsp.Body.Close()
+
+			if rsp.StatusCode != tc.want {
+				t.Errorf("got status %d, want %d", rsp.StatusCode, tc.want)
+			}
+			if attempts != tc.attempts {
+				t.Errorf("got %d attempts, want %d", attempts, tc.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportConnectionReset(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rsp, err := testRetryClient(2).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	rsp.Body.Close()
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	var attempts int32
+	var first time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if wait := time.Since(first); wait < time.Second {
+			t.Errorf("retried after %s, want at least 1s", wait)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// Retry-After is honored up to the maximum wait.
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, 1, time.Millisecond, 2*time.Second)}
+	rsp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want 200", rsp.StatusCode)
+	}
+}
+
+func TestRetryTransportRetryAfterCapped(t *testing.T) {
+	tr := newRetryTransport(nil, 10, time.Second, 30*time.Second)
+	for _, v := range []string{"3600", time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat)} {
+		rsp := &http.Response{Header: http.Header{"Retry-After": {v}}}
+		if wait := tr.backoff(0, rsp); wait != 30*time.Second {
+			t.Errorf("Retry-After %s: got wait %s, want 30s", v, wait)
+		}
+	}
+	rsp := &http.Response{Header: http.Header{"Retry-After": {"5"}}}
+	if wait := tr.backoff(0, rsp); wait != 5*time.Second {
+		t.Errorf("Retry-After 5: got wait %s, want 5s", wait)
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	tr := newRetryTransport(nil, 10, time.Second, 8*time.Second)
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		wait := tr.backoff(attempt, nil)
+		if wait < max/2 || wait > max {
+			t.Errorf("attempt %d: got wait %s, want between %s and %s", attempt, wait, max/2, max)
+		}
+	}
+}
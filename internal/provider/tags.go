@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	nb "github.com/nautobot/go-nautobot/pkg/nautobot"
+)
+
+// nestedTag is a tag nested in a Nautobot object, the part of
+// nb.TagSerializerField used by the provider. Its dates aren't decoded as
+// they are datetimes with Nautobot 2.x, which also only nests the ID of tags
+// by default.
+type nestedTag struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Slug string `json:"slug,omitempty"`
+}
+
+// tagsSchema is the tags attribute of taggable resources.
+func tagsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: "Tags, by name or slug. Slugs are only supported by Nautobot 1.x.",
+	}
+}
+
+// decodeTags returns the tags nested in an object returned by Nautobot.
+func decodeTags(v interface{}) ([]nestedTag, error) {
+	var tags []nestedTag
+	if v == nil {
+		return tags, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// tags returns the tags of Nautobot, fetching them on the first call or when
+// refresh is set.
+func (c *apiClient) tags(ctx context.Context, refresh bool) ([]nestedTag, diag.Diagnostics) {
+	c.tagsMu.Lock()
+	defer c.tagsMu.Unlock()
+
+	if c.tagList != nil && !refresh {
+		return c.tagList, nil
+	}
+
+	list, diags := listAll(ctx, fmt.Sprintf("get tags from %s", c.Server), 0, func(ctx context.Context, limit *int, offset *int) (*http.Response, []byte, error) {
+		rsp, err := c.Client.ExtrasTagsListWithResponse(
+			ctx,
+			&nb.ExtrasTagsListParams{
+				Limit:  limit,
+				Offset: offset,
+			})
+		if err != nil {
+			return nil, nil, err
+		}
+		return rsp.HTTPResponse, rsp.Body, nil
+	})
+	if diags != nil {
+		return nil, diags
+	}
+
+	tags := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		tags = append(tags, item)
+	}
+	tagList, err := decodeTags(tags)
+	if err != nil {
+		return nil, diag.Errorf("failed to decode tags from %s: %s", c.Server, err.Error())
+	}
+	c.tagList = tagList
+
+	return c.tagList, nil
+}
+
+// expandTags resolves the tags of a resource, given by name or slug, to
+// their IDs.
+func expandTags(ctx context.Context, meta interface{}, refs *schema.Set) ([]string, diag.Diagnostics) {
+	c := meta.(*apiClient)
+	if refs.Len() == 0 {
+		return []string{}, nil
+	}
+
+	tags, diags := c.tags(ctx, false)
+	if diags != nil {
+		return nil, diags
+	}
+	ids, missing := resolveTags(tags, refs)
+	// The tags may have been created since they were fetched.
+	if len(missing) > 0 {
+		if tags, diags = c.tags(ctx, true); diags != nil {
+			return nil, diags
+		}
+		ids, missing = resolveTags(tags, refs)
+	}
+
+	for _, ref := range missing {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Unknown tag",
+			Detail:        fmt.Sprintf("There is no tag named %s on %s.", ref, c.Server),
+			AttributePath: cty.GetAttrPath("tags"),
+		})
+	}
+	if diags != nil {
+		return nil, diags
+	}
+	return ids, nil
+}
+
+// resolveTags returns the IDs of the tags named refs, and the refs which
+// don't name any tag.
+func resolveTags(tags []nestedTag, refs *schema.Set) ([]string, []string) {
+	var ids, missing []string
+	for _, ref := range refs.List() {
+		if tag := findTag(tags, ref.(string)); tag != nil {
+			ids = append(ids, tag.ID)
+		} else {
+			missing = append(missing, ref.(string))
+		}
+	}
+	return ids, missing
+}
+
+// flattenTags returns the tags nested in an object returned by Nautobot as
+// the tags of a resource. Tags keep the slug used in declared, the tags of
+// the resource, or else are named by name.
+func flattenTags(ctx context.Context, meta interface{}, declared *schema.Set, v interface{}) ([]interface{}, diag.Diagnostics) {
+	c := meta.(*apiClient)
+	nested, err := decodeTags(v)
+	if err != nil {
+		return nil, diag.Errorf("failed to decode tags from %s: %s", c.Server, err.Error())
+	}
+
+	refs := make([]interface{}, 0, len(nested))
+	for _, tag := range nested {
+		// Nautobot 2.x only nests the ID of tags.
+		if tag.Name == "" {
+			tags, diags := c.tags(ctx, false)
+			if diags != nil {
+				return nil, diags
+			}
+			if findTagID(tags, tag.ID) == nil {
+				if tags, diags = c.tags(ctx, true); diags != nil {
+					return nil, diags
+				}
+			}
+			if t := findTagID(tags, tag.ID); t != nil {
+				tag = *t
+			}
+		}
+
+		switch {
+		case tag.Slug != "" && declared.Contains(tag.Slug):
+			refs = append(refs, tag.Slug)
+		case tag.Name != "":
+			refs = append(refs, tag.Name)
+		default:
+			refs = append(refs, tag.ID)
+		}
+	}
+	return refs, nil
+}
+
+func findTag(tags []nestedTag, ref string) *nestedTag {
+	for i, tag := range tags {
+		if tag.Name == ref || (tag.Slug != "" && tag.Slug == ref) {
+			return &tags[i]
+		}
+	}
+	return nil
+}
+
+func findTagID(tags []nestedTag, id string) *nestedTag {
+	for i, tag := range tags {
+		if tag.ID == id {
+			return &tags[i]
+		}
+	}
+	return nil
+}
+
+// withTags returns the JSON encoding of the request body with the tags ids,
+// for the models of the generated client which have no tags.
+func withTags(body interface{}, ids []string) (io.Reader, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if ids == nil {
+		return bytes.NewReader(raw), nil
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	m["tags"] = ids
+	raw, err = json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(raw), nil
+}
+
+// tagsUnsupported is the warning returned when tags are set on an object
+// which Nautobot doesn't tag, as it then ignores them.
+func tagsUnsupported(kind string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity:      diag.Warning,
+		Summary:       "Tags not supported",
+		Detail:        fmt.Sprintf("This version of Nautobot doesn't support tags on %ss, they were ignored.", kind),
+		AttributePath: cty.GetAttrPath("tags"),
+	}}
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"io"
+	"testing"
+
+	nb "github.com/nautobot/go-nautobot/pkg/nautobot"
+)
+
+func TestWithTags(t *testing.T) {
+	description := "Routers"
+	for _, tc := range []struct {
+		ids  []string
+		want string
+	}{
+		{nil, `{"description":"Routers","name":"Juniper"}`},
+		{[]string{}, `{"description":"Routers","name":"Juniper","tags":[]}`},
+		{[]string{"4b4d1a5c-95a4-4d2c-8e38-2d0f4f0b3f5a"}, `{"description":"Routers","name":"Juniper","tags":["4b4d1a5c-95a4-4d2c-8e38-2d0f4f0b3f5a"]}`},
+	} {
+		body, err := withTags(nb.ManufacturerRequest{Name: "Juniper", Description: &description}, tc.ids)
+		if err != nil {
+			t.Fatalf("%v: unexpected error %s", tc.ids, err)
+		}
+		got, _ := io.ReadAll(body)
+		if string(got) != tc.want {
+			t.Errorf("%v: got %s, want %s", tc.ids, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeTags(t *testing.T) {
+	tags, err := decodeTags([]interface{}{
+		map[string]interface{}{"id": "1", "name": "Lab", "slug": "lab", "color": "9e9e9e", "created": "2023-01-31"},
+		map[string]interface{}{"id": "2", "object_type": "extras.tag", "url": "https://nautobot.example.com/api/extras/tags/2/"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(tags) != 2 || tags[0] != (nestedTag{ID: "1", Name: "Lab", Slug: "lab"}) || tags[1] != (nestedTag{ID: "2"}) {
+		t.Errorf("got %v", tags)
+	}
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// errRequestTimeout is returned when Nautobot didn't answer a request within
+// the request_timeout of the provider.
+var errRequestTimeout = errors.New("request timed out")
+
+// defaultResourceTimeouts returns the timeouts of the resources, which can be
+// overridden in their timeouts block. The SDK applies them to the context
+// given to the CRUD functions, and so to every request they make.
+func defaultResourceTimeouts() *schema.ResourceTimeout {
+	return &schema.ResourceTimeout{
+		Create: schema.DefaultTimeout(5 * time.Minute),
+		Read:   schema.DefaultTimeout(5 * time.Minute),
+		Update: schema.DefaultTimeout(5 * time.Minute),
+		Delete: schema.DefaultTimeout(5 * time.Minute),
+	}
+}
+
+// timeoutTransport gives up on a request when no complete response has been
+// received within timeout. Each attempt of a retried request gets its own
+// deadline.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	rsp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && req.Context().Err() == nil {
+			return nil, fmt.Errorf("%w after %s", errRequestTimeout, t.timeout)
+		}
+		return nil, err
+	}
+
+	// The deadline applies until the response body has been read.
+	rsp.Body = &cancelOnClose{ReadCloser: rsp.Body, cancel: cancel}
+	return rsp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
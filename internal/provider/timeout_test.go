@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	var attempts int32
+	// hanging is signaled when the first attempt starts hanging.
+	hanging := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the first attempt hangs.
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			select {
+			case hanging <- struct{}{}:
+			default:
+			}
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	timeout := &timeoutTransport{next: http.DefaultTransport, timeout: 50 * time.Millisecond}
+
+	t.Run("gives up", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		_, err := (&http.Client{Transport: timeout}).Get(srv.URL)
+		if !errors.Is(err, errRequestTimeout) {
+			t.Fatalf("got error %v, want %v", err, errRequestTimeout)
+		}
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		client := &http.Client{Transport: newRetryTransport(timeout, 1, time.Millisecond, time.Millisecond)}
+		rsp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		rsp.Body.Close()
+		if n := atomic.LoadInt32(&attempts); n != 2 {
+			t.Errorf("got %d attempts, want 2", n)
+		}
+	})
+
+	t.Run("canceled by caller", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		select {
+		case <-hanging:
+		default:
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// Cancel once the request reached the server, so that it is attempted.
+		go func() {
+			<-hanging
+			cancel()
+		}()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		client := &http.Client{Transport: newRetryTransport(timeout, 1, time.Millisecond, time.Millisecond)}
+		_, err := client.Do(req)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got error %v, want %v", err, context.Canceled)
+		}
+		if n := atomic.LoadInt32(&attempts); n != 1 {
+			t.Errorf("got %d attempts, want 1", n)
+		}
+	})
+}
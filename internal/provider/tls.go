@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// tlsSettings holds the TLS arguments of the provider. Certificates and keys
+// can be given either as a path to a PEM file or inline as PEM.
+type tlsSettings struct {
+	CACertFile         string
+	CACertPEM          string
+	ClientCertFile     string
+	ClientKeyFile      string
+	ClientCertPEM      string
+	ClientKeyPEM       string
+	InsecureSkipVerify bool
+}
+
+// tlsConfig builds the TLS configuration shared by every request made to
+// Nautobot. It returns nil when the defaults of the system apply.
+func (s tlsSettings) tlsConfig() (*tls.Config, error) {
+	if s == (tlsSettings{}) {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// The user has to opt in explicitly.
+		InsecureSkipVerify: s.InsecureSkipVerify, //nolint:gosec
+	}
+
+	caPEM, err := readPEM(s.CACertFile, s.CACertPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	if caPEM != nil {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid PEM certificate found in the CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+
+	certPEM, err := readPEM(s.ClientCertFile, s.ClientCertPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	keyPEM, err := readPEM(s.ClientKeyFile, s.ClientKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+	switch {
+	case certPEM != nil && keyPEM != nil:
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	case certPEM != nil:
+		return nil, fmt.Errorf("a client certificate was given without a client key")
+	case keyPEM != nil:
+		return nil, fmt.Errorf("a client key was given without a client certificate")
+	}
+
+	return cfg, nil
+}
+
+// readPEM returns the content of file if set, or else the inline PEM.
+func readPEM(file, inline string) ([]byte, error) {
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+// newTestCert generates a certificate signed by parent, or a self-signed CA
+// when parent is nil.
+func newTestCert(t *testing.T, parent *testCert, server bool) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "nautobot-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := tmpl, key
+	switch {
+	case parent == nil:
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	case server:
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+		signer, signerKey = parent.cert, parent.key
+	default:
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+// newMutualTLSServer starts a server whose certificate is signed by ca and
+// that only accepts clients presenting a certificate signed by ca.
+func newMutualTLSServer(t *testing.T, ca *testCert) *httptest.Server {
+	t.Helper()
+
+	serverCert := newTestCert(t, ca, true)
+	pair, err := tls.X509KeyPair([]byte(serverCert.certPEM), []byte(serverCert.keyPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/status/" {
+			w.Write([]byte(`{"nautobot-version":"1.5.8"}`))
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestProviderMutualTLS(t *testing.T) {
+	ca := newTestCert(t, nil, false)
+	client := newTestCert(t, ca, false)
+	srv := newMutualTLSServer(t, ca)
+
+	cases := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "ca and client certificate",
+			config: map[string]interface{}{
+				"ca_cert_pem":     ca.certPEM,
+				"client_cert_pem": client.certPEM,
+				"client_key_pem":  client.keyPEM,
+			},
+		},
+		{
+			name: "insecure with client certificate",
+			config: map[string]interface{}{
+				"insecure_skip_verify": true,
+				"client_cert_pem":      client.certPEM,
+				"client_key_pem":       client.keyPEM,
+			},
+		},
+		{
+			name: "missing client certificate",
+			config: map[string]interface{}{
+				"ca_cert_pem": ca.certPEM,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown CA",
+			config:  map[string]interface{}{},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config["url"] = srv.URL + "/api/"
+			tc.config["token"] = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+			// Configuring the provider queries /api/status/ with Client.
+			p := New("dev")()
+			diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(tc.config))
+			if tc.wantErr {
+				if !diags.HasError() {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected error: %v", diags)
+			}
+
+			// The raw GraphQL calls go through BaseClient, check it got the TLS settings.
+			c := p.Meta().(*apiClient).BaseClient
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/graphql/", nil)
+			rsp, err := c.Client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			rsp.Body.Close()
+		})
+	}
+}
+
+func TestTLSSettings(t *testing.T) {
+	ca := newTestCert(t, nil, false)
+
+	cases := []struct {
+		name     string
+		settings tlsSettings
+		wantErr  bool
+	}{
+		{"defaults", tlsSettings{}, false},
+		{"inline CA", tlsSettings{CACertPEM: ca.certPEM}, false},
+		{"invalid CA", tlsSettings{CACertPEM: "not a certificate"}, true},
+		{"missing CA file", tlsSettings{CACertFile: "/does/not/exist.pem"}, true},
+		{"certificate without key", tlsSettings{ClientCertPEM: ca.certPEM}, true},
+		{"key without certificate", tlsSettings{ClientKeyPEM: ca.keyPEM}, true},
+		{"mismatched key", tlsSettings{ClientCertPEM: ca.certPEM, ClientKeyPEM: newTestCert(t, nil, false).keyPEM}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.settings.tlsConfig()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got error %v, want error: %t", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// tokenLength is the length of the keys of Nautobot API tokens.
+const tokenLength = 40
+
+// tokenCommandTimeout is how long token_command may run.
+const tokenCommandTimeout = time.Minute
+
+var (
+	// tokenCommandCache holds the tokens returned by token_command, so that
+	// the credential helper runs only once even if the provider is configured
+	// several times.
+	tokenCommandCache   = map[string]string{}
+	tokenCommandCacheMu sync.Mutex
+)
+
+// validateToken makes sure t looks like a Nautobot API token.
+func validateToken(t string) error {
+	if t == "" {
+		return fmt.Errorf("the token is empty")
+	}
+	if strings.HasPrefix(t, "Token ") || strings.HasPrefix(t, "Bearer ") {
+		return fmt.Errorf("the token must not start with %q, the provider adds the prefix itself", strings.Fields(t)[0]+" ")
+	}
+	for i, r := range t {
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return fmt.Errorf("the token contains an invalid character %q at position %d", r, i+1)
+		}
+	}
+	if n := len([]rune(t)); n != tokenLength {
+		return fmt.Errorf("the token is %d characters long, but Nautobot tokens are %d characters long", n, tokenLength)
+	}
+	return nil
+}
+
+// providerToken returns the API token from the token, token_file or
+// token_command arguments, in that order of precedence, along with the name
+// of the argument it came from.
+func providerToken(ctx context.Context, d *schema.ResourceData) (string, string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	token, hasToken := d.GetOk("token")
+	file, hasFile := d.GetOk("token_file")
+	command, hasCommand := d.GetOk("token_command")
+
+	sources := 0
+	for _, ok := range []bool{hasToken, hasFile, hasCommand} {
+		if ok {
+			sources++
+		}
+	}
+	if sources > 1 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Several token sources set",
+			Detail:   "More than one of token, token_file and token_command is set, token takes precedence over token_file, which takes precedence over token_command.",
+		})
+	}
+
+	switch {
+	case hasToken:
+		return token.(string), "token", diags
+	case hasFile:
+		t, err := readTokenFile(file.(string))
+		if err != nil {
+			return "", "", append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Failed to read token_file",
+				Detail:        err.Error(),
+				AttributePath: cty.GetAttrPath("token_file"),
+			})
+		}
+		return t, "token_file", diags
+	case hasCommand:
+		args := []string{}
+		for _, a := range command.([]interface{}) {
+			args = append(args, a.(string))
+		}
+		t, err := runTokenCommand(ctx, args)
+		if err != nil {
+			return "", "", append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Failed to run token_command",
+				Detail:        err.Error(),
+				AttributePath: cty.GetAttrPath("token_command"),
+			})
+		}
+		return t, "token_command", diags
+	}
+
+	return "", "", append(diags, diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  "Missing token",
+		Detail:   "One of token, token_file or token_command must be set, or the NAUTOBOT_TOKEN environment variable.",
+	})
+}
+
+// readTokenFile reads the token stored in path. Leading and trailing
+// whitespace, such as a final newline, is ignored.
+func readTokenFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// runTokenCommand runs a credential helper and returns what it printed on its
+// standard output. The result is cached for the life of the provider.
+func runTokenCommand(ctx context.Context, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "" {
+		return "", fmt.Errorf("the command is empty")
+	}
+
+	key := strings.Join(args, "\x00")
+
+	tokenCommandCacheMu.Lock()
+	defer tokenCommandCacheMu.Unlock()
+
+	if t, ok := tokenCommandCache[key]; ok {
+		return t, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	tflog.Debug(ctx, "running token_command", map[string]interface{}{
+		"command": args[0],
+	})
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %s", args[0], err)
+	}
+
+	t := strings.TrimSpace(stdout.String())
+	tokenCommandCache[key] = t
+	return t, nil
+}
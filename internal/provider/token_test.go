@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef01234567"
+
+func TestValidateToken(t *testing.T) {
+	cases := map[string]string{
+		testToken:                              "",
+		"":                                     "the token is empty",
+		"Token " + testToken:                   `must not start with "Token "`,
+		testToken[:39]:                         "39 characters long",
+		testToken + "8":                        "41 characters long",
+		testToken[:20] + "\n" + testToken[21:]: `invalid character '\n' at position 21`,
+	}
+	for token, want := range cases {
+		err := validateToken(token)
+		if want == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error: %s", token, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got error %v, want %q", token, err, want)
+		}
+	}
+}
+
+func TestProviderTokenSources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Token "+testToken {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"nautobot-version":"1.5.8"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	os.WriteFile(tokenFile, []byte(testToken+"\n"), 0o600)
+	badTokenFile := filepath.Join(dir, "bad-token")
+	os.WriteFile(badTokenFile, []byte("Token "+testToken+"\n"), 0o600)
+
+	// The helper counts how many times it runs.
+	counter := filepath.Join(dir, "runs")
+	helper := []interface{}{"sh", "-c", "echo run >> " + counter + "; echo " + testToken}
+
+	cases := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{"token", map[string]interface{}{"token": testToken}, ""},
+		{"token_file", map[string]interface{}{"token_file": tokenFile}, ""},
+		{"token_command", map[string]interface{}{"token_command": helper}, ""},
+		{"token_command cached", map[string]interface{}{"token_command": helper}, ""},
+		{"missing", map[string]interface{}{}, "Missing token"},
+		{"malformed token", map[string]interface{}{"token": "abc"}, "Invalid token"},
+		{"malformed token_file", map[string]interface{}{"token_file": badTokenFile}, "Invalid token"},
+		{"unreadable token_file", map[string]interface{}{"token_file": filepath.Join(dir, "missing")}, "Failed to read token_file"},
+		{"failing token_command", map[string]interface{}{"token_command": []interface{}{"sh", "-c", "echo denied >&2; exit 2"}}, "Failed to run token_command"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NAUTOBOT_TOKEN", "")
+			tc.config["url"] = srv.URL + "/api/"
+
+			diags := New("dev")().Configure(context.Background(), terraform.NewResourceConfigRaw(tc.config))
+			if tc.wantErr == "" {
+				if diags.HasError() {
+					t.Fatalf("unexpected error: %v", diags)
+				}
+				return
+			}
+			if !diags.HasError() || diags[len(diags)-1].Summary != tc.wantErr {
+				t.Fatalf("got %v, want %q", diags, tc.wantErr)
+			}
+		})
+	}
+
+	runs, _ := os.ReadFile(counter)
+	if n := strings.Count(string(runs), "run"); n != 1 {
+		t.Errorf("token_command ran %d times, want 1", n)
+	}
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/tidwall/gjson"
+
+	nb "github.com/nautobot/go-nautobot/pkg/nautobot"
+)
+
+var (
+	// defaultAPIVersions are the REST API versions requested when api_version
+	// is not set, by major version of Nautobot, so that upgrading Nautobot
+	// doesn't change the responses we get.
+	defaultAPIVersions = map[int]string{
+		1: "1.3",
+		2: "2.0",
+	}
+
+	// supportedNautobotVersions are the Nautobot releases the provider works with.
+	supportedNautobotVersions = version.MustConstraints(version.NewConstraint(">= 1.3.0, < 3.0.0"))
+
+	// supportedAPIVersions are the REST API versions the provider can request.
+	supportedAPIVersions = version.MustConstraints(version.NewConstraint(">= 1.2, < 3.0"))
+
+	apiVersionRegexp = regexp.MustCompile(`^\d+\.\d+$`)
+)
+
+// acceptAPIVersion returns a request editor that asks Nautobot for version *v
+// of the REST API, or for its default version while *v is empty. *v is read
+// on every request, as the version is only known once the version of
+// Nautobot has been checked when api_version is not set.
+func acceptAPIVersion(v *string) nb.RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		if *v == "" {
+			req.Header.Set("Accept", "application/json")
+			return nil
+		}
+		req.Header.Set("Accept", fmt.Sprintf("application/json; version=%s", *v))
+		return nil
+	}
+}
+
+// majorVersion returns the major version of the REST API version v, which
+// is also the major version of Nautobot.
+func majorVersion(v string) int {
+	return version.Must(version.NewVersion(v)).Segments()[0]
+}
+
+func validateAPIVersion(v interface{}, path cty.Path) diag.Diagnostics {
+	s := v.(string)
+	if !apiVersionRegexp.MatchString(s) {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Invalid API version",
+			Detail:        fmt.Sprintf("%q is not a valid Nautobot REST API version, it must look like \"1.3\".", s),
+			AttributePath: path,
+		}}
+	}
+	if !supportedAPIVersions.Check(version.Must(version.NewVersion(s))) {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Unsupported API version",
+			Detail:        fmt.Sprintf("Nautobot REST API version %s is not supported by this provider, supported versions are %s.", s, supportedAPIVersions),
+			AttributePath: path,
+		}}
+	}
+	return nil
+}
+
+// checkServerCompatibility makes sure, from its answer to /api/status/, that
+// the server runs a Nautobot release supported by the provider and accepts
+// apiVersion, if set. It returns the version of Nautobot.
+func checkServerCompatibility(ctx context.Context, rsp *nb.StatusRetrieveResponse, s string, apiVersion string) (*version.Version, diag.Diagnostics) {
+	if rsp.StatusCode() == http.StatusNotAcceptable {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unsupported API version",
+			Detail:   fmt.Sprintf("%s does not support REST API version %s: %s", s, apiVersion, string(rsp.Body)),
+		}}
+	}
+	if rsp.StatusCode() != http.StatusOK {
+		return nil, diag.Errorf("failed to get the status of %s: %s", s, rsp.Status())
+	}
+
+	serverVersion := gjson.Get(string(rsp.Body), "nautobot-version").String()
+	v, err := version.NewVersion(serverVersion)
+	if err != nil {
+		return nil, diag.Errorf("failed to parse Nautobot version %q returned by %s: %s", serverVersion, s, err.Error())
+	}
+	if !supportedNautobotVersions.Check(v.Core()) {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unsupported Nautobot version",
+			Detail:   fmt.Sprintf("%s runs Nautobot %s, but this provider supports Nautobot %s.", s, serverVersion, supportedNautobotVersions),
+		}}
+	}
+
+	if got := rsp.HTTPResponse.Header.Get("API-Version"); apiVersion != "" && got != "" && got != apiVersion {
+		return nil, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unexpected API version",
+			Detail:   fmt.Sprintf("%s answered with REST API version %s instead of the requested %s.", s, got, apiVersion),
+		}}
+	}
+
+	tflog.Debug(ctx, "connected to Nautobot", map[string]interface{}{
+		"nautobot_version": serverVersion,
+		"api_version":      apiVersion,
+	})
+
+	return v, nil
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestServerCompatibility(t *testing.T) {
+	cases := []struct {
+		name           string
+		serverVersion  string
+		apiVersion     string
+		skipPreflight  bool
+		wantAPIVersion string
+		wantErr        string
+	}{
+		{name: "supported", serverVersion: "1.5.8", wantAPIVersion: "1.3"},
+		{name: "pinned", serverVersion: "1.5.8", apiVersion: "1.4", wantAPIVersion: "1.4"},
+		{name: "nautobot 2", serverVersion: "2.1.4", wantAPIVersion: "2.0"},
+		{name: "nautobot 2 pinned", serverVersion: "2.1.4", apiVersion: "2.1", wantAPIVersion: "2.1"},
+		{name: "skip preflight", serverVersion: "2.1.4", apiVersion: "2.0", skipPreflight: true, wantAPIVersion: "2.0"},
+		{name: "skip preflight without API version", serverVersion: "2.1.4", skipPreflight: true, wantErr: "Missing api_version"},
+		{name: "too old", serverVersion: "1.2.11", wantErr: "Unsupported Nautobot version"},
+		{name: "too new", serverVersion: "3.0.0", wantErr: "Unsupported Nautobot version"},
+		{name: "unknown API version", serverVersion: "1.3.0", apiVersion: "1.5", wantErr: "Unsupported API version"},
+		{name: "API version of Nautobot 1", serverVersion: "2.0.0", apiVersion: "1.3", wantErr: "Unsupported API version"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var status int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/users/tokens/" {
+					w.Write([]byte(`{"count":0,"results":[]}`))
+					return
+				}
+				if r.URL.Path != "/api/status/" {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+				status++
+
+				// Without api_version, Nautobot is first asked for its
+				// default version.
+				want := "application/json"
+				if tc.apiVersion != "" {
+					want = "application/json; version=" + tc.apiVersion
+				}
+				if got := r.Header.Get("Accept"); got != want {
+					t.Errorf("got Accept header %q, want %q", got, want)
+				}
+
+				// Nautobot supports the API versions of its own major version,
+				// up to its minor version.
+				serverAPIVersion := tc.serverVersion[:strings.LastIndex(tc.serverVersion, ".")]
+				apiVersion := serverAPIVersion
+				if _, v, ok := strings.Cut(r.Header.Get("Accept"), "version="); ok {
+					apiVersion = v
+				}
+				if apiVersion[0] != serverAPIVersion[0] || apiVersion > serverAPIVersion {
+					w.WriteHeader(http.StatusNotAcceptable)
+					w.Write([]byte(`{"detail":"Invalid version in \"Accept\" header."}`))
+					return
+				}
+				w.Header().Set("API-Version", apiVersion)
+				w.Write([]byte(`{"nautobot-version":"` + tc.serverVersion + `"}`))
+			}))
+			defer srv.Close()
+
+			config := map[string]interface{}{
+				"url":            srv.URL + "/api/",
+				"token":          "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+				"skip_preflight": tc.skipPreflight,
+			}
+			if tc.apiVersion != "" {
+				config["api_version"] = tc.apiVersion
+			}
+
+			p := New("dev")()
+			diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(config))
+			if tc.wantErr == "" {
+				if diags.HasError() {
+					t.Fatalf("unexpected error: %v", diags)
+				}
+				if got := p.Meta().(*apiClient).APIVersion; got != tc.wantAPIVersion {
+					t.Errorf("got API version %s, want %s", got, tc.wantAPIVersion)
+				}
+				if tc.skipPreflight && status > 0 {
+					t.Error("got a request to /api/status/ with skip_preflight")
+				}
+				return
+			}
+			if !diags.HasError() || diags[0].Summary != tc.wantErr {
+				t.Fatalf("got %v, want %q", diags, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAPIVersion(t *testing.T) {
+	for v, valid := range map[string]bool{
+		"1.2":    true,
+		"1.5":    true,
+		"1.1":    false,
+		"2.0":    true,
+		"3.0":    false,
+		"1":      false,
+		"1.3.0":  false,
+		"latest": false,
+	} {
+		if diags := validateAPIVersion(v, cty.GetAttrPath("api_version")); diags.HasError() == valid {
+			t.Errorf("%q: got %v, want valid: %t", v, diags, valid)
+		}
+	}
+}